@@ -0,0 +1,263 @@
+// Package abnf loads grammars written in Augmented BNF (RFC 5234, with the
+// case sensitive strings of RFC 7405) and builds them with the combinators of
+// this module at runtime, like the grammars of many protocol specifications:
+//
+//	; comments start with ";"
+//	date    = year "-" month "-" day
+//	year    = 4DIGIT
+//	month   = "0" %x31-39 / "1" %x30-32
+//	day     = 2DIGIT
+//
+// Rule names are case insensitive and the core rules of RFC 5234 (ALPHA,
+// DIGIT, CRLF, HEXDIG...) can be used without defining them. Quoted strings
+// are case insensitive unless prefixed by %s.
+//
+// ABNF alternatives and repetitions are compiled to ordered choices and
+// greedy loops that don't backtrack, as in PEGs: when an alternative matches
+// a prefix of another one the longer must come first, for example
+// "25" %x30-35 / DIGIT instead of DIGIT / "25" %x30-35.
+//
+// Results are strings for terminals, []interface{} for concatenations and
+// repetitions, the result of the matching alternative for alternations and
+// nil for missing optional elements, see Text.
+package abnf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// Actions are semantic actions by rule name (case insensitive), each one
+// transforms the result of its rule as combinators.Transform does
+type Actions map[string]func(interface{}) interface{}
+
+// Grammar is a loaded rule list
+type Grammar struct {
+	// Start is the name of the first rule
+	Start string
+	// Rules are the parsers of the rules by name as written in their
+	// definitions, each one is Named after its rule. Referenced core rules
+	// are included.
+	Rules map[string]c.Parser
+
+	byName map[string]c.Parser
+}
+
+// LoadGrammar reads a rule list and builds a Parser for each of its rules,
+// actions can be nil
+func LoadGrammar(r io.Reader, actions Actions) (*Grammar, error) {
+	result, err := c.ParseRuneReader(rulelist, bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return build(result.([]*definition), actions)
+}
+
+// Rule returns the parser of a rule by its case insensitive name, or nil
+func (g *Grammar) Rule(name string) c.Parser {
+	return g.byName[strings.ToLower(name)]
+}
+
+// Parse parses the input with the first rule
+func (g *Grammar) Parse(r io.RuneReader, opts ...c.ParseOption) (interface{}, error) {
+	return c.ParseRuneReader(g.Rules[g.Start], r, opts...)
+}
+
+// Text concatenates the strings in a result skipping missing optional
+// elements, for rules without actions that's the matched text
+func Text(result interface{}) string {
+	switch v := result.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		sb := &strings.Builder{}
+		for _, item := range v {
+			sb.WriteString(Text(item))
+		}
+		return sb.String()
+	}
+
+	return fmt.Sprint(result)
+}
+
+// builder builds the parser of an element once all the rules are known
+type builder func(l *loader) c.Parser
+
+type definition struct {
+	name string
+	// incremental is true for "=/" definitions adding alternatives to a rule
+	incremental  bool
+	alternatives []builder
+}
+
+type reference struct {
+	from, to string
+}
+
+// loader holds the rules being built, references are resolved lazily so
+// rules can be recursive and defined in any order
+type loader struct {
+	rules map[string]c.Parser
+	// rule is the name of the rule being built
+	rule       string
+	references []reference
+	err        error
+}
+
+func (l *loader) reference(name string) c.Parser {
+	l.references = append(l.references, reference{l.rule, name})
+
+	key := strings.ToLower(name)
+	return c.Lazy(func() c.Parser {
+		return l.rules[key]
+	})
+}
+
+func build(definitions []*definition, actions Actions) (*Grammar, error) {
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf(`Empty grammar`)
+	}
+
+	// merge incremental alternatives keeping the order of the first definitions
+	merged := []*definition{}
+	byName := map[string]*definition{}
+	for _, def := range definitions {
+		key := strings.ToLower(def.name)
+
+		first, ok := byName[key]
+		switch {
+		case def.incremental && !ok:
+			return nil, fmt.Errorf(`Rule "%s" is extended with "=/" before being defined`, def.name)
+		case def.incremental:
+			first.alternatives = append(first.alternatives, def.alternatives...)
+		case ok:
+			return nil, fmt.Errorf(`Rule "%s" is defined more than once`, def.name)
+		default:
+			copied := *def
+			byName[key] = &copied
+			merged = append(merged, &copied)
+		}
+	}
+
+	g := &Grammar{Start: merged[0].name, Rules: map[string]c.Parser{}, byName: map[string]c.Parser{}}
+	l := &loader{rules: g.byName}
+
+	lowerActions := map[string]func(interface{}) interface{}{}
+	for name, action := range actions {
+		lowerActions[strings.ToLower(name)] = action
+	}
+
+	for _, def := range merged {
+		l.rule = def.name
+
+		body := alternation(def.alternatives)(l)
+		if action, ok := lowerActions[strings.ToLower(def.name)]; ok {
+			body = c.Transform(body, action)
+		}
+
+		parser := c.Named(def.name, body)
+		g.Rules[def.name] = parser
+		g.byName[strings.ToLower(def.name)] = parser
+	}
+
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	for _, ref := range l.references {
+		key := strings.ToLower(ref.to)
+		if _, ok := g.byName[key]; ok {
+			continue
+		}
+
+		coreRule := core().Rule(key)
+		if coreRule == nil {
+			return nil, fmt.Errorf(`Undefined rule "%s" referenced by "%s"`, ref.to, ref.from)
+		}
+
+		name := strings.ToUpper(ref.to)
+		g.Rules[name] = coreRule
+		g.byName[key] = coreRule
+	}
+
+	names := []string{}
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := g.byName[strings.ToLower(name)]; !ok {
+			return nil, fmt.Errorf(`Action for undefined rule "%s"`, name)
+		}
+	}
+
+	return g, nil
+}
+
+func alternation(alternatives []builder) builder {
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+
+	return func(l *loader) c.Parser {
+		parsers := []c.Parser{}
+		for _, alternative := range alternatives {
+			parsers = append(parsers, alternative(l))
+		}
+
+		return c.AnyOf(parsers...)
+	}
+}
+
+// repeat matches between min and max occurrences of a parser, max is
+// negative when unbounded. It's built from SeqOf, Optional and ZeroOrMore so
+// that it's still introspectable, the Result is a flat []interface{}.
+func repeat(parser c.Parser, min, max int) c.Parser {
+	parsers := []c.Parser{}
+	for i := 0; i < min; i++ {
+		parsers = append(parsers, parser)
+	}
+
+	switch {
+	case max < 0:
+		parsers = append(parsers, c.ZeroOrMore(parser))
+	case max > min:
+		var optional c.Parser = c.Optional(c.SeqOf(parser))
+		for i := min + 1; i < max; i++ {
+			optional = c.Optional(c.SeqOf(parser, optional))
+		}
+		parsers = append(parsers, optional)
+	}
+
+	return c.Transform(c.SeqOf(parsers...), func(i interface{}) interface{} {
+		seq := i.([]interface{})
+		results := append([]interface{}{}, seq[:min]...)
+
+		if max < 0 {
+			return append(results, seq[min].([]interface{})...)
+		}
+
+		if max > min {
+			for rest := seq[min]; rest != nil; {
+				more := rest.([]interface{})
+				results = append(results, more[0])
+
+				rest = nil
+				if len(more) > 1 {
+					rest = more[1]
+				}
+			}
+		}
+
+		return results
+	})
+}
@@ -0,0 +1,46 @@
+package abnf
+
+import (
+	"strings"
+	"sync"
+)
+
+// coreABNF are the core rules of appendix B.1 of RFC 5234
+const coreABNF = `
+ALPHA   =  %x41-5A / %x61-7A
+BIT     =  "0" / "1"
+CHAR    =  %x01-7F
+CR      =  %x0D
+CRLF    =  CR LF
+CTL     =  %x00-1F / %x7F
+DIGIT   =  %x30-39
+DQUOTE  =  %x22
+HEXDIG  =  DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+HTAB    =  %x09
+LF      =  %x0A
+LWSP    =  *(WSP / CRLF WSP)
+OCTET   =  %x00-FF
+SP      =  %x20
+VCHAR   =  %x21-7E
+WSP     =  SP / HTAB
+`
+
+var (
+	coreOnce  sync.Once
+	coreRules *Grammar
+)
+
+// core returns the core rules, they are loaded on first use as the syntax of
+// rule lists is only complete after the package initialization
+func core() *Grammar {
+	coreOnce.Do(func() {
+		g, err := LoadGrammar(strings.NewReader(coreABNF), nil)
+		if err != nil {
+			panic(err)
+		}
+
+		coreRules = g
+	})
+
+	return coreRules
+}
@@ -0,0 +1,353 @@
+package abnf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// The syntax of rule lists, following section 4 of RFC 5234. Lines can end
+// with CRLF or just LF.
+
+var wsp = c.ExpectAny([]rune(" \t"))
+
+var newline = c.SeqOf(c.Optional(c.Expect('\r')), c.Expect('\n'))
+
+var comment = c.SeqOf(
+	c.Expect(';'),
+	c.ZeroOrMore(c.ExpectPredicate(func(r rune) bool { return r != '\r' && r != '\n' }, "not newline")),
+	c.AnyOf(newline, c.EOF),
+)
+
+// cnl is a comment or a newline
+var cnl = c.AnyOf(comment, newline)
+
+// cwsp is whitespace that can continue on the next line if it starts with whitespace
+var cwsp = c.AnyOf(wsp, c.SeqOf(cnl, wsp))
+
+var skip = c.SeqIgnore(c.ZeroOrMore(cwsp))
+
+var asciiLetter = c.ExpectPredicate(func(r rune) bool { return r < unicode.MaxASCII && unicode.IsLetter(r) }, "letter")
+
+var asciiDigit = c.ExpectPredicate(func(r rune) bool { return '0' <= r && r <= '9' }, "digit")
+
+var rulename = c.Label(
+	c.StringifyResult(
+		c.SeqOf(asciiLetter, c.ZeroOrMore(c.AnyOf(asciiLetter, asciiDigit, c.Expect('-')))),
+	),
+	"rule name",
+)
+
+var definedAs = c.Transform(
+	c.SeqOf(skip, c.Label(c.AnyOf(c.ExpectString([]rune("=/")), c.Expect('=')), `"="`), skip),
+	func(i interface{}) interface{} {
+		return i.([]interface{})[0]
+	},
+)
+
+var number = c.Transform(
+	c.StringifyResult(c.OneOrMore(asciiDigit)),
+	func(i interface{}) interface{} {
+		n, _ := strconv.Atoi(i.(string))
+		return n
+	},
+)
+
+type bounds struct {
+	min, max int
+}
+
+// repeatSyntax is "n", "n*m", "n*", "*m" or "*", max is -1 when unbounded
+var repeatSyntax = c.AnyOf(
+	c.Transform(
+		c.SeqOf(c.Optional(number), c.Expect('*'), c.Optional(number)),
+		func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			b := bounds{0, -1}
+			if seq[0] != nil {
+				b.min = seq[0].(int)
+			}
+			if seq[2] != nil {
+				b.max = seq[2].(int)
+			}
+			return b
+		},
+	),
+	c.Transform(number, func(i interface{}) interface{} {
+		return bounds{i.(int), i.(int)}
+	}),
+)
+
+var alternationRef = c.Lazy(func() c.Parser {
+	return alternationSyntax
+})
+
+var group = c.Transform(
+	c.SeqOf(c.SeqIgnore(c.Expect('(')), skip, alternationBuilder, skip, c.SeqIgnore(c.Expect(')'))),
+	func(i interface{}) interface{} {
+		return i.([]interface{})[0]
+	},
+)
+
+var option = c.Transform(
+	c.SeqOf(c.SeqIgnore(c.Expect('[')), skip, alternationBuilder, skip, c.SeqIgnore(c.Expect(']'))),
+	func(i interface{}) interface{} {
+		inner := i.([]interface{})[0].(builder)
+		return builder(func(l *loader) c.Parser {
+			return c.Optional(inner(l))
+		})
+	},
+)
+
+var charVal = c.Transform(
+	c.SeqOf(
+		c.Optional(c.AnyOf(c.ExpectString([]rune("%s")), c.ExpectString([]rune("%i")))),
+		c.SeqIgnore(c.Expect('"')),
+		c.StringifyResult(c.ZeroOrMore(c.ExpectPredicate(func(r rune) bool {
+			return r >= 0x20 && r <= 0x7E && r != '"'
+		}, "quoted string character"))),
+		c.SeqIgnore(c.Label(c.Expect('"'), `closing '"'`)),
+	),
+	func(i interface{}) interface{} {
+		seq := i.([]interface{})
+		text := seq[1].(string)
+		if seq[0] == "%s" {
+			return builder(func(l *loader) c.Parser {
+				return c.ExpectString([]rune(text))
+			})
+		}
+
+		return builder(func(l *loader) c.Parser {
+			return caseInsensitive(text)
+		})
+	},
+)
+
+// caseInsensitive matches a string ignoring the case of its ASCII letters,
+// the Result is the matched text
+func caseInsensitive(text string) c.Parser {
+	parsers := []c.Parser{}
+	for _, r := range text {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		if lower == upper {
+			parsers = append(parsers, c.Expect(r))
+		} else {
+			parsers = append(parsers, c.ExpectAny([]rune{lower, upper}))
+		}
+	}
+
+	if len(parsers) == 1 {
+		return parsers[0]
+	}
+
+	return c.StringifyResult(c.SeqOf(parsers...))
+}
+
+// numValBase parses the digits of a numeric value after its base letter:
+// a single value, a range "a-b" or a concatenation "a.b.c"
+func numValBase(letters string, base int, digits string) c.Parser {
+	value := c.Transform(
+		c.StringifyResult(c.OneOrMore(c.ExpectAny([]rune(digits)))),
+		func(i interface{}) interface{} {
+			n, _ := strconv.ParseUint(i.(string), base, 32)
+			return rune(n)
+		},
+	)
+
+	prefix := map[int]string{2: "%b", 10: "%d", 16: "%x"}[base]
+
+	return c.Transform(
+		c.SeqOf(
+			c.SeqIgnore(c.ExpectAny([]rune(letters))),
+			value,
+			c.Optional(c.AnyOf(
+				c.SeqOf(c.Expect('-'), value),
+				c.OneOrMore(c.SeqOf(c.SeqIgnore(c.Expect('.')), value)),
+			)),
+		),
+		func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			first := seq[0].(rune)
+
+			if seq[1] == nil {
+				return builder(func(l *loader) c.Parser {
+					return c.Expect(first)
+				})
+			}
+
+			rest := seq[1].([]interface{})
+			if rest[0] == "-" {
+				last := rest[1].(rune)
+				description := prefix + strings.ToUpper(strconv.FormatInt(int64(first), base)+"-"+strconv.FormatInt(int64(last), base))
+				return builder(func(l *loader) c.Parser {
+					return c.ExpectPredicate(func(r rune) bool { return first <= r && r <= last }, description)
+				})
+			}
+
+			runes := []rune{first}
+			for _, item := range rest {
+				runes = append(runes, item.([]interface{})[0].(rune))
+			}
+			return builder(func(l *loader) c.Parser {
+				return c.ExpectString(runes)
+			})
+		},
+	)
+}
+
+var numVal = c.SeqOf(
+	c.SeqIgnore(c.Expect('%')),
+	c.AnyOf(
+		numValBase("bB", 2, "01"),
+		numValBase("dD", 10, "0123456789"),
+		numValBase("xX", 16, "0123456789abcdefABCDEF"),
+	),
+)
+
+// proseVal is an informal description that can't be compiled, it's only
+// allowed in repetitions of zero elements like "0<pchar>"
+var proseVal = c.Transform(
+	c.SeqOf(
+		c.SeqIgnore(c.Expect('<')),
+		c.StringifyResult(c.ZeroOrMore(c.ExpectPredicate(func(r rune) bool {
+			return r >= 0x20 && r <= 0x7E && r != '>'
+		}, "prose character"))),
+		c.SeqIgnore(c.Expect('>')),
+	),
+	func(i interface{}) interface{} {
+		text := i.([]interface{})[0].(string)
+		return builder(func(l *loader) c.Parser {
+			if l.err == nil {
+				l.err = fmt.Errorf(`Prose value <%s> in rule "%s" can't be compiled`, text, l.rule)
+			}
+			return c.SeqOf()
+		})
+	},
+)
+
+var element = c.AnyOf(
+	c.Transform(rulename, func(i interface{}) interface{} {
+		name := i.(string)
+		return builder(func(l *loader) c.Parser {
+			return l.reference(name)
+		})
+	}),
+	group,
+	option,
+	charVal,
+	c.Transform(numVal, func(i interface{}) interface{} {
+		return i.([]interface{})[0]
+	}),
+	proseVal,
+)
+
+var repetition = c.Transform(
+	c.SeqOf(c.Optional(repeatSyntax), element),
+	func(i interface{}) interface{} {
+		seq := i.([]interface{})
+		inner := seq[1].(builder)
+		if seq[0] == nil {
+			return inner
+		}
+
+		b := seq[0].(bounds)
+		return builder(func(l *loader) c.Parser {
+			if b.max == 0 {
+				return c.SeqOf()
+			}
+
+			return repeat(inner(l), b.min, b.max)
+		})
+	},
+)
+
+var concatenation = c.Transform(
+	c.SeqOf(repetition, c.ZeroOrMore(c.SeqOf(c.SeqIgnore(c.OneOrMore(cwsp)), repetition))),
+	func(i interface{}) interface{} {
+		seq := i.([]interface{})
+		items := []builder{seq[0].(builder)}
+		for _, item := range seq[1].([]interface{}) {
+			items = append(items, item.([]interface{})[0].(builder))
+		}
+
+		if len(items) == 1 {
+			return items[0]
+		}
+
+		return builder(func(l *loader) c.Parser {
+			parsers := []c.Parser{}
+			for _, item := range items {
+				parsers = append(parsers, item(l))
+			}
+
+			return c.SeqOf(parsers...)
+		})
+	},
+)
+
+// alternationSyntax results in the []builder of the alternatives, it's
+// recursive through groups and options and it's assigned in init to break
+// the initialization cycle
+var alternationSyntax c.Parser
+
+func init() {
+	alternationSyntax = c.Transform(
+		c.SeqOf(concatenation, c.ZeroOrMore(c.SeqOf(skip, c.SeqIgnore(c.Expect('/')), skip, concatenation))),
+		func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			alternatives := []builder{seq[0].(builder)}
+			for _, alternative := range seq[1].([]interface{}) {
+				alternatives = append(alternatives, alternative.([]interface{})[0].(builder))
+			}
+
+			return alternatives
+		},
+	)
+}
+
+// alternationBuilder is alternationRef as a single builder, as used by groups and options
+var alternationBuilder = c.Transform(alternationRef, func(i interface{}) interface{} {
+	return alternation(i.([]builder))
+})
+
+var rule = c.Named("Rule",
+	c.Transform(
+		c.SeqOf(
+			rulename,
+			definedAs,
+			alternationRef,
+			skip,
+			c.SeqIgnore(c.Label(c.AnyOf(cnl, c.EOF), "end of rule")),
+		),
+		func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			return &definition{
+				name:         seq[0].(string),
+				incremental:  seq[1] == "=/",
+				alternatives: seq[2].([]builder),
+			}
+		},
+	),
+)
+
+var blankLine = c.SeqOf(c.ZeroOrMore(wsp), cnl)
+
+var rulelist = c.Named("Rulelist",
+	c.Transform(
+		c.RepeatUntil(
+			c.SeqOf(c.SeqIgnore(c.ZeroOrMore(blankLine)), rule),
+			c.SeqOf(c.ZeroOrMore(blankLine), c.EOF),
+		),
+		func(i interface{}) interface{} {
+			definitions := []*definition{}
+			for _, item := range i.([]interface{}) {
+				definitions = append(definitions, item.([]interface{})[0].(*definition))
+			}
+
+			return definitions
+		},
+	),
+)
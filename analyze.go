@@ -0,0 +1,676 @@
+package combinators
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WarningKind classifies the problems found by Analyze
+type WarningKind string
+
+// Kinds of grammar warnings
+const (
+	// NullableLoop is a repetition of a parser that can succeed without
+	// consuming input, that loops forever
+	NullableLoop WarningKind = "nullable loop"
+	// LeftRecursion is a rule that can call itself without consuming input,
+	// that recurses forever
+	LeftRecursion WarningKind = "left recursion"
+	// ShadowedAlternative is an alternative of AnyOf that is never tried
+	// because an earlier one always matches first
+	ShadowedAlternative WarningKind = "shadowed alternative"
+)
+
+// Warning is a problem of a grammar found by Analyze
+type Warning struct {
+	Kind WarningKind
+	// Rule is the name of the Named rule containing the problem, if any
+	Rule string
+	// Parser is the offending combinator
+	Parser  Parser
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Rule == "" {
+		return fmt.Sprintf("%s: %s", w.Kind, w.Message)
+	}
+
+	return fmt.Sprintf("%s in %s: %s", w.Kind, w.Rule, w.Message)
+}
+
+// FirstSet is the set of runes a parser can start with
+type FirstSet struct {
+	// Runes are sorted and without duplicates
+	Runes []rune
+	// Predicates are the predicates of the ExpectPredicate parsers the parser can start with
+	Predicates []func(rune) bool
+	// EOF tells the parser can succeed at the end of the input without consuming anything
+	EOF bool
+	// Any tells the parser is too dynamic to be analyzed, like FuncParsers,
+	// and can start with anything
+	Any bool
+}
+
+// Matches tells if the parser can start with the given rune, zero is the end of the input
+func (f FirstSet) Matches(r rune) bool {
+	if f.Any {
+		return true
+	}
+
+	if r == 0 {
+		return f.EOF
+	}
+
+	i := sort.Search(len(f.Runes), func(i int) bool { return f.Runes[i] >= r })
+	if i < len(f.Runes) && f.Runes[i] == r {
+		return true
+	}
+
+	for _, predicate := range f.Predicates {
+		if predicate(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// firstSet is the growing FIRST set of an analysisNode
+type firstSet struct {
+	runes map[rune]bool
+	// classes are ExpectPredicate nodes, compared by identity as functions can't be
+	classes []*analysisNode
+	eof     bool
+	any     bool
+}
+
+func (f *firstSet) addRune(r rune) bool {
+	if f.runes[r] {
+		return false
+	}
+
+	if f.runes == nil {
+		f.runes = map[rune]bool{}
+	}
+	f.runes[r] = true
+	return true
+}
+
+func (f *firstSet) addClass(n *analysisNode) bool {
+	for _, class := range f.classes {
+		if class == n {
+			return false
+		}
+	}
+
+	f.classes = append(f.classes, n)
+	return true
+}
+
+func (f *firstSet) setEOF() bool {
+	changed := !f.eof
+	f.eof = true
+	return changed
+}
+
+func (f *firstSet) setAny() bool {
+	changed := !f.any
+	f.any = true
+	return changed
+}
+
+func (f *firstSet) union(other *firstSet) bool {
+	changed := false
+
+	for r := range other.runes {
+		changed = f.addRune(r) || changed
+	}
+	for _, class := range other.classes {
+		changed = f.addClass(class) || changed
+	}
+	if other.eof {
+		changed = f.setEOF() || changed
+	}
+	if other.any {
+		changed = f.setAny() || changed
+	}
+
+	return changed
+}
+
+type analysisNode struct {
+	parser   Parser
+	desc     Description
+	rule     string
+	children []*analysisNode
+
+	nullable bool
+	first    firstSet
+}
+
+// Analysis is the result of Analyze
+type Analysis struct {
+	Warnings []Warning
+
+	nodes map[Parser]*analysisNode
+	order []*analysisNode
+}
+
+// Analyze computes the nullable parsers and the FIRST sets of a grammar using
+// the Descriptions of its combinators, without running it on any input, and
+// reports repetitions of nullable parsers, left recursive rules and
+// alternatives shadowed by earlier ones. FuncParsers and other opaque parsers
+// are assumed to start with anything.
+func Analyze(parser Parser) *Analysis {
+	a := analyzeFirst(parser)
+
+	a.checkLoops()
+	a.checkLeftRecursion(a.order[0])
+	a.checkAlternatives()
+
+	return a
+}
+
+// analyzeFirst computes only the nullable parsers and the FIRST sets, without the warnings
+func analyzeFirst(parser Parser) *Analysis {
+	a := &Analysis{nodes: map[Parser]*analysisNode{}}
+	a.node(parser, "")
+	a.solve()
+
+	return a
+}
+
+// Err returns an error listing all the warnings, or nil if there are none
+func (a *Analysis) Err() error {
+	if len(a.Warnings) == 0 {
+		return nil
+	}
+
+	lines := []string{}
+	for _, w := range a.Warnings {
+		lines = append(lines, fmt.Sprintf(" - %v", w))
+	}
+
+	return fmt.Errorf("Grammar has %d warnings:\n%s", len(a.Warnings), strings.Join(lines, "\n"))
+}
+
+// Nullable tells if a parser of the analyzed grammar can succeed without consuming input
+func (a *Analysis) Nullable(parser Parser) bool {
+	if n := a.lookup(parser); n != nil {
+		return n.nullable
+	}
+
+	return false
+}
+
+// First returns the FIRST set of a parser of the analyzed grammar, parsers
+// that are not part of it start with anything
+func (a *Analysis) First(parser Parser) FirstSet {
+	n := a.lookup(parser)
+	if n == nil {
+		return FirstSet{Any: true}
+	}
+
+	first := FirstSet{EOF: n.first.eof, Any: n.first.any}
+	for r := range n.first.runes {
+		first.Runes = append(first.Runes, r)
+	}
+	sort.Slice(first.Runes, func(i, j int) bool { return first.Runes[i] < first.Runes[j] })
+
+	for _, class := range n.first.classes {
+		first.Predicates = append(first.Predicates, class.desc.Predicate)
+	}
+
+	return first
+}
+
+func (a *Analysis) lookup(parser Parser) *analysisNode {
+	if !reflect.TypeOf(parser).Comparable() {
+		return nil
+	}
+
+	return a.nodes[parser]
+}
+
+// node builds the graph of the grammar, parsers reachable from more than one
+// path share their node
+func (a *Analysis) node(parser Parser, rule string) *analysisNode {
+	comparable := reflect.TypeOf(parser).Comparable()
+	if comparable {
+		if n, ok := a.nodes[parser]; ok {
+			return n
+		}
+	}
+
+	n := &analysisNode{parser: parser, desc: Describe(parser), rule: rule}
+	if n.desc.Kind == KindNamed {
+		n.rule = n.desc.Label
+	}
+
+	if comparable {
+		a.nodes[parser] = n
+	}
+	a.order = append(a.order, n)
+
+	for _, child := range n.desc.Children {
+		n.children = append(n.children, a.node(child, n.rule))
+	}
+
+	return n
+}
+
+// solve computes nullable and FIRST sets as the least fixed point of update,
+// recursive rules just need more iterations
+func (a *Analysis) solve() {
+	for changed := true; changed; {
+		changed = false
+
+		for _, n := range a.order {
+			changed = a.update(n) || changed
+		}
+	}
+}
+
+func (a *Analysis) update(n *analysisNode) bool {
+	first := &n.first
+	changed := false
+	nullable := false
+
+	switch n.desc.Kind {
+	case KindExpect:
+		changed = first.addRune([]rune(n.desc.Text)[0])
+	case KindExpectAny:
+		for _, r := range n.desc.Text {
+			changed = first.addRune(r) || changed
+		}
+	case KindExpectString, KindExpectTokenText:
+		if n.desc.Text == "" {
+			nullable = true
+		} else {
+			changed = first.addRune([]rune(n.desc.Text)[0])
+		}
+	case KindExpectPredicate:
+		changed = first.addClass(n)
+	case KindEOF:
+		changed = first.setEOF()
+	case KindGetState, KindSetState, KindNot, KindLookahead:
+		nullable = true
+	case KindTrivia:
+		nullable = true
+		changed = first.setAny()
+
+	case KindSeqOf:
+		nullable = true
+		for _, child := range n.children {
+			changed = first.union(&child.first) || changed
+			if !child.nullable {
+				nullable = false
+				break
+			}
+		}
+	case KindAnyOf:
+		for _, child := range n.children {
+			changed = first.union(&child.first) || changed
+			nullable = nullable || child.nullable
+		}
+	case KindZeroOrMore, KindOptional:
+		changed = first.union(&n.children[0].first)
+		nullable = true
+	case KindRepeatUntil:
+		// the terminator is tried first and is not consumed, so it succeeds
+		// without consuming input when the terminator matches right away
+		changed = first.union(&n.children[0].first)
+		changed = first.union(&n.children[1].first) || changed
+		nullable = true
+	case KindSameIndent:
+		changed = first.addRune(' ')
+		changed = first.addRune('\t') || changed
+		changed = first.union(&n.children[0].first) || changed
+		nullable = n.children[0].nullable
+	case KindIndentGreater:
+		changed = first.addRune(' ')
+		changed = first.addRune('\t') || changed
+	case KindLexeme:
+		changed = first.union(&n.children[0].first)
+		if n.children[0].nullable {
+			changed = first.setAny() || changed
+		}
+		nullable = n.children[0].nullable
+
+	case KindSeqIgnore, KindTransform, KindTransformErr, KindLazy, KindMemoize, KindNamed, KindLabel, KindOneOrMore,
+		KindRestarableOneOrMore, KindVerbatim, KindIndented, KindUpdateState, KindCheckState:
+		changed = first.union(&n.children[0].first)
+		nullable = n.children[0].nullable
+
+	default:
+		changed = first.setAny()
+	}
+
+	if nullable && !n.nullable {
+		n.nullable = true
+		changed = true
+	}
+
+	return changed
+}
+
+func (a *Analysis) warn(kind WarningKind, n *analysisNode, format string, args ...interface{}) {
+	a.Warnings = append(a.Warnings, Warning{kind, n.rule, n.parser, fmt.Sprintf(format, args...)})
+}
+
+func (a *Analysis) checkLoops() {
+	for _, n := range a.order {
+		switch n.desc.Kind {
+		case KindZeroOrMore, KindRepeatUntil:
+			// ZeroOrMore stops at the end of the input by itself
+			if body := n.children[0]; body.nullable {
+				a.warn(NullableLoop, n, "%s repeats %s that can succeed without consuming input", n.desc.Kind, display(body))
+			}
+		case KindOneOrMore:
+			if body := n.children[0]; body.nullable || body.first.eof {
+				a.warn(NullableLoop, n, "%s repeats %s that can succeed without consuming input", n.desc.Kind, display(body))
+			}
+		}
+	}
+}
+
+// leftChildren returns the children a node can apply at its own position
+func leftChildren(n *analysisNode) []*analysisNode {
+	switch n.desc.Kind {
+	case KindSeqOf:
+		for i, child := range n.children {
+			if !child.nullable {
+				return n.children[:i+1]
+			}
+		}
+	case KindIndentGreater:
+		return nil
+	case KindRestarableOneOrMore:
+		return n.children[:1]
+	}
+
+	return n.children
+}
+
+func (a *Analysis) checkLeftRecursion(root *analysisNode) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	status := map[*analysisNode]int{}
+	stack := []*analysisNode{}
+
+	var visit func(n *analysisNode)
+	visit = func(n *analysisNode) {
+		status[n] = visiting
+		stack = append(stack, n)
+
+		for _, child := range leftChildren(n) {
+			switch status[child] {
+			case unvisited:
+				visit(child)
+			case visiting:
+				for i := range stack {
+					if stack[i] == child {
+						a.warn(LeftRecursion, child, "%s can call itself without consuming input: %s", display(child), cyclePath(stack[i:]))
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		status[n] = visited
+	}
+
+	visit(root)
+}
+
+// cyclePath describes a cycle by its rules, or by its combinators if it doesn't go through any rule
+func cyclePath(cycle []*analysisNode) string {
+	names := []string{}
+	for _, n := range cycle {
+		if n.desc.Kind == KindNamed {
+			names = append(names, n.desc.Label)
+		}
+	}
+
+	if len(names) == 0 {
+		for _, n := range cycle {
+			names = append(names, n.desc.String())
+		}
+	}
+
+	return strings.Join(append(names, names[0]), " > ")
+}
+
+func (a *Analysis) checkAlternatives() {
+	for _, n := range a.order {
+		if n.desc.Kind != KindAnyOf {
+			continue
+		}
+
+		for j, later := range n.children {
+			for i, earlier := range n.children[:j] {
+				if a.shadows(earlier, later) {
+					a.warn(ShadowedAlternative, n, "alternative %d %s is never tried, alternative %d %s always matches first", j+1, display(later), i+1, display(earlier))
+					break
+				}
+			}
+		}
+	}
+}
+
+// shadows tells if every input matched by later is also matched by earlier
+func (a *Analysis) shadows(earlier, later *analysisNode) bool {
+	if accepted, ok := accepts(earlier, map[*analysisNode]bool{}); ok {
+		if strings.HasPrefix(prefix(later, map[*analysisNode]bool{}), accepted) {
+			return true
+		}
+	}
+
+	class := runeClass(earlier)
+	if class == nil || later.nullable || later.first.eof || later.first.any {
+		return false
+	}
+	if len(later.first.runes) == 0 && len(later.first.classes) == 0 {
+		return false
+	}
+
+	for r := range later.first.runes {
+		if !class.matches(r) {
+			return false
+		}
+	}
+	for _, c := range later.first.classes {
+		if c != class {
+			return false
+		}
+	}
+
+	return true
+}
+
+// transparent tells if a kind of combinator matches exactly what its only child does
+func transparent(kind Kind) bool {
+	switch kind {
+	case KindSeqIgnore, KindTransform, KindLazy, KindMemoize, KindNamed, KindLabel, KindVerbatim, KindUpdateState:
+		return true
+	}
+
+	return false
+}
+
+// unwrap skips the transparent combinators around a node
+func unwrap(n *analysisNode) *analysisNode {
+	seen := map[*analysisNode]bool{}
+	for transparent(n.desc.Kind) && !seen[n] {
+		seen[n] = true
+		n = n.children[0]
+	}
+
+	return n
+}
+
+// exact returns the text a node always matches and nothing more, if any
+func exact(n *analysisNode, seen map[*analysisNode]bool) (string, bool) {
+	if seen[n] {
+		return "", false
+	}
+	seen[n] = true
+	defer delete(seen, n)
+
+	switch n.desc.Kind {
+	case KindExpect, KindExpectString:
+		return n.desc.Text, true
+	case KindSeqOf:
+		text := ""
+		for _, child := range n.children {
+			childText, ok := exact(child, seen)
+			if !ok {
+				return "", false
+			}
+			text += childText
+		}
+		return text, true
+	}
+
+	if transparent(n.desc.Kind) {
+		return exact(n.children[0], seen)
+	}
+
+	return "", false
+}
+
+// accepts returns a text such that the node succeeds on every input starting with it
+func accepts(n *analysisNode, seen map[*analysisNode]bool) (string, bool) {
+	if text, ok := exact(n, seen); ok {
+		return text, true
+	}
+
+	if seen[n] {
+		return "", false
+	}
+	seen[n] = true
+	defer delete(seen, n)
+
+	switch n.desc.Kind {
+	case KindOptional, KindZeroOrMore, KindTrivia, KindGetState, KindSetState:
+		return "", true
+	case KindOneOrMore, KindLexeme:
+		return accepts(n.children[0], seen)
+	case KindSeqOf:
+		text := ""
+		for i, child := range n.children {
+			if childText, ok := exact(child, seen); ok {
+				text += childText
+				continue
+			}
+
+			childText, ok := accepts(child, seen)
+			if !ok {
+				return "", false
+			}
+
+			// after an inexact child only parsers that always succeed can follow
+			for _, next := range n.children[i+1:] {
+				if nextText, ok := accepts(next, seen); !ok || nextText != "" {
+					return "", false
+				}
+			}
+
+			return text + childText, true
+		}
+		return text, true
+	}
+
+	if transparent(n.desc.Kind) {
+		return accepts(n.children[0], seen)
+	}
+
+	return "", false
+}
+
+// prefix returns the text every match of the node starts with
+func prefix(n *analysisNode, seen map[*analysisNode]bool) string {
+	if text, ok := exact(n, seen); ok {
+		return text
+	}
+
+	if seen[n] {
+		return ""
+	}
+	seen[n] = true
+	defer delete(seen, n)
+
+	switch n.desc.Kind {
+	case KindOneOrMore, KindLexeme, KindIndented:
+		return prefix(n.children[0], seen)
+	case KindSeqOf:
+		text := ""
+		for _, child := range n.children {
+			childText, ok := exact(child, seen)
+			if !ok {
+				return text + prefix(child, seen)
+			}
+			text += childText
+		}
+		return text
+	}
+
+	if transparent(n.desc.Kind) {
+		return prefix(n.children[0], seen)
+	}
+
+	return ""
+}
+
+// runeClass returns the node matching a single rune a node reduces to, if any
+func runeClass(n *analysisNode) *analysisNode {
+	n = unwrap(n)
+
+	switch n.desc.Kind {
+	case KindExpect, KindExpectAny, KindExpectPredicate:
+		return n
+	}
+
+	return nil
+}
+
+// matches tells if the single rune node matches a rune
+func (n *analysisNode) matches(r rune) bool {
+	switch n.desc.Kind {
+	case KindExpectPredicate:
+		return n.desc.Predicate(r)
+	default:
+		return strings.ContainsRune(n.desc.Text, r)
+	}
+}
+
+// display describes a node in warnings, by its rule name or literal text when possible
+func display(n *analysisNode) string {
+	seen := map[*analysisNode]bool{}
+	for !seen[n] {
+		seen[n] = true
+
+		if n.desc.Kind == KindNamed {
+			return n.desc.Label
+		}
+		if !transparent(n.desc.Kind) {
+			break
+		}
+
+		n = n.children[0]
+	}
+
+	if text, ok := exact(n, map[*analysisNode]bool{}); ok {
+		return fmt.Sprintf("%q", text)
+	}
+
+	return n.desc.String()
+}
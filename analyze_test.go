@@ -0,0 +1,101 @@
+package combinators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeFirstSets(t *testing.T) {
+	sign := Optional(ExpectAny([]rune("+-")))
+	number := SeqOf(sign, OneOrMore(Digit))
+	value := AnyOf(number, ExpectString([]rune("null")), EOF)
+
+	a := Analyze(value)
+	assert.Nil(t, a.Err())
+
+	assert.True(t, a.Nullable(sign))
+	assert.False(t, a.Nullable(number))
+	assert.False(t, a.Nullable(value))
+
+	first := a.First(value)
+	assert.Equal(t, []rune("+-n"), first.Runes)
+	assert.Len(t, first.Predicates, 1)
+	assert.True(t, first.EOF)
+	assert.False(t, first.Any)
+
+	assert.True(t, first.Matches('7'))
+	assert.True(t, first.Matches('n'))
+	assert.True(t, first.Matches(0))
+	assert.False(t, first.Matches('x'))
+
+	assert.True(t, Analyze(SeqOf(Digit, Expect('a'))).First(Expect('a')).Any, "parsers outside of the grammar start with anything")
+}
+
+func TestAnalyzeWarnings(t *testing.T) {
+	{
+		loop := ZeroOrMore(Optional(Expect('a')))
+		a := Analyze(Named("Loop", loop))
+
+		assert.Equal(t, []Warning{
+			{NullableLoop, "Loop", loop, "ZeroOrMore repeats Optional that can succeed without consuming input"},
+		}, a.Warnings)
+		assert.Nil(t, Analyze(OneOrMore(Expect('a'))).Err())
+		assert.NotNil(t, Analyze(OneOrMore(EOF)).Err(), "OneOrMore doesn't stop at the end of the input")
+
+		lines := ZeroOrMore(RepeatUntil(Any, Newline))
+		assert.Equal(t, []Warning{
+			{NullableLoop, "", lines, "ZeroOrMore repeats RepeatUntil that can succeed without consuming input"},
+		}, Analyze(lines).Warnings, "RepeatUntil doesn't consume the terminator")
+	}
+	{
+		// grammar builds an expression grammar where Term can be an expression in parentheses
+		grammar := func(build func(exprRef, term Parser) Parser) Parser {
+			var expr Parser
+			exprRef := Lazy(func() Parser { return expr })
+			term := Named("Term", AnyOf(Digit, SeqOf(Expect('('), exprRef, Expect(')'))))
+			expr = build(exprRef, term)
+			return expr
+		}
+
+		a := Analyze(grammar(func(exprRef, term Parser) Parser {
+			return Named("Expr", AnyOf(SeqOf(exprRef, Expect('+'), term), term))
+		}))
+		assert.Len(t, a.Warnings, 1)
+		assert.Equal(t, "left recursion in Expr: Expr can call itself without consuming input: Expr > Expr", a.Warnings[0].String())
+
+		a = Analyze(grammar(func(exprRef, term Parser) Parser {
+			return Named("Expr", SeqOf(Optional(Expect('-')), Named("Sum", AnyOf(term, SeqOf(exprRef, term)))))
+		}))
+		assert.EqualError(t, a.Err(), "Grammar has 1 warnings:\n - left recursion in Expr: Expr can call itself without consuming input: Expr > Sum > Expr")
+
+		a = Analyze(grammar(func(exprRef, term Parser) Parser {
+			return Named("Expr", SeqOf(term, Optional(SeqOf(Expect('+'), exprRef))))
+		}))
+		assert.Nil(t, a.Err())
+	}
+	{
+		a := Analyze(AnyOf(ExpectString([]rune("=")), ExpectString([]rune("=="))))
+		assert.Equal(t, []string{
+			`shadowed alternative: alternative 2 "==" is never tried, alternative 1 "=" always matches first`,
+		}, warningStrings(a))
+
+		a = Analyze(AnyOf(Letter, SeqOf(Expect('x'), Digit), Named("Rest", Optional(Digit)), Expect('!')))
+		assert.Equal(t, []string{
+			`shadowed alternative: alternative 2 SeqOf is never tried, alternative 1 ExpectPredicate(letter) always matches first`,
+			`shadowed alternative: alternative 4 "!" is never tried, alternative 3 Rest always matches first`,
+		}, warningStrings(a))
+
+		assert.Nil(t, Analyze(AnyOf(ExpectString([]rune("==")), ExpectString([]rune("=")))).Err())
+		assert.Nil(t, Analyze(Alphanumeric).Err())
+		assert.Nil(t, Analyze(AnyOf(SeqOf(Expect('a'), Expect('b')), SeqOf(Expect('a'), Expect('c')))).Err())
+	}
+}
+
+func warningStrings(a *Analysis) []string {
+	lines := []string{}
+	for _, w := range a.Warnings {
+		lines = append(lines, w.String())
+	}
+	return lines
+}
@@ -0,0 +1,64 @@
+package combinators
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RuneRange is an inclusive range of runes
+type RuneRange struct {
+	Lo, Hi rune
+}
+
+// CharClass is a set of runes given as ranges, like the character classes of regular expressions
+type CharClass struct {
+	Negated bool
+	Ranges  []RuneRange
+}
+
+// Matches tells if a rune belongs to the class
+func (class CharClass) Matches(r rune) bool {
+	for _, rr := range class.Ranges {
+		if rr.Lo <= r && r <= rr.Hi {
+			return !class.Negated
+		}
+	}
+
+	return class.Negated
+}
+
+// String returns the class in the usual notation, like "[^a-z\]]"
+func (class CharClass) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString("[")
+	if class.Negated {
+		sb.WriteString("^")
+	}
+
+	for _, rr := range class.Ranges {
+		sb.WriteString(classText(rr.Lo))
+		if rr.Lo != rr.Hi {
+			sb.WriteString("-" + classText(rr.Hi))
+		}
+	}
+	sb.WriteString("]")
+
+	return sb.String()
+}
+
+// classText escapes a rune of a character class
+func classText(r rune) string {
+	if strings.ContainsRune("]-^", r) {
+		return `\` + string(r)
+	}
+
+	return strings.Trim(strconv.QuoteRune(r), "'")
+}
+
+// ExpectClass is an ExpectPredicate matching the runes of a character class,
+// its Description has the class so tools don't need to call the predicate
+func ExpectClass(class CharClass) Parser {
+	p := ExpectPredicate(class.Matches, class.String()).(*combinator)
+	p.desc.Class = &class
+	return p
+}
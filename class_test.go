@@ -0,0 +1,25 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectClass(t *testing.T) {
+	class := CharClass{Negated: true, Ranges: []RuneRange{{Lo: 'a', Hi: 'z'}, {Lo: ']', Hi: ']'}}}
+	parser := ExpectClass(class)
+
+	assert.Equal(t, `[^a-z\]]`, class.String())
+	assert.Equal(t, &class, Describe(parser).Class)
+	assert.Equal(t, KindExpectPredicate, Describe(parser).Kind)
+	assert.Nil(t, Describe(ExpectPredicate(class.Matches, class.String())).Class)
+
+	r, err := ParseRuneReader(parser, strings.NewReader("A"))
+	assert.Nil(t, err)
+	assert.Equal(t, "A", r)
+
+	_, err = ParseRuneReader(parser, strings.NewReader("]"))
+	assert.EqualError(t, err, `Expected "[[^a-z\]]]"`)
+}
@@ -0,0 +1,142 @@
+// Command parcomb-gen generates a standalone Go parser from a PEG grammar, see
+// packages peg and gen. It's meant to be used with go generate:
+//
+//	//go:generate go run github.com/aziis98/parser-combinators/cmd/parcomb-gen -peg arith.peg -test
+//
+// With -test it also generates a test checking that the generated parser and
+// the grammar loaded by package peg agree on the files of a corpus directory.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/aziis98/parser-combinators/gen"
+	"github.com/aziis98/parser-combinators/peg"
+)
+
+var agreementTest = template.Must(template.New("test").Parse(`// Code generated by parcomb-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aziis98/parser-combinators/gen"
+	"github.com/aziis98/parser-combinators/peg"
+)
+
+const {{.Prefix}}Grammar = {{.Grammar}}
+
+func Test{{.Type}}AgreesWithGrammar(t *testing.T) {
+	g, err := peg.LoadGrammar(strings.NewReader({{.Prefix}}Grammar), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corpus, err := gen.ReadCorpus({{.Corpus}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gen.CheckAgreement(g.Rules[g.Start], (&{{.Type}}{}).Parse, corpus); err != nil {
+		t.Error(err)
+	}
+}
+`))
+
+func main() {
+	pegFile := flag.String("peg", "", "the PEG grammar file")
+	output := flag.String("o", "", `the generated file, by default the grammar file with the "_gen.go" extension`)
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "the package of the generated file, by default the one of go generate")
+	typeName := flag.String("type", "Parser", "the name of the generated parser type")
+	test := flag.Bool("test", false, "also generate a test checking the generated parser agrees with the grammar on a corpus")
+	corpus := flag.String("corpus", "testdata/corpus", "the corpus directory of the test")
+	flag.Parse()
+
+	if *pegFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(*pegFile, filepath.Ext(*pegFile)) + "_gen.go"
+	}
+	if *pkg == "" {
+		*pkg = "main"
+	}
+
+	source, err := ioutil.ReadFile(*pegFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g, err := peg.LoadGrammar(bytes.NewReader(source), nil)
+	if err != nil {
+		log.Fatalf("%s: %v", *pegFile, err)
+	}
+
+	names := []string{}
+	for name := range g.Rules {
+		if name != g.Start {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	rules := []c.Parser{}
+	for _, name := range names {
+		rules = append(rules, g.Rules[name])
+	}
+
+	code := &bytes.Buffer{}
+	opts := gen.Options{Package: *pkg, Type: *typeName, Source: filepath.Base(*pegFile), Rules: rules}
+	if err := gen.Generate(code, g.Rules[g.Start], opts); err != nil {
+		log.Fatalf("%s: %v", *pegFile, err)
+	}
+
+	if err := ioutil.WriteFile(*output, code.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	if !*test {
+		return
+	}
+
+	grammar := strconv.Quote(string(source))
+	if !strings.Contains(string(source), "`") {
+		grammar = "`" + string(source) + "`"
+	}
+
+	testCode := &bytes.Buffer{}
+	if err := agreementTest.Execute(testCode, map[string]string{
+		"Source":  opts.Source,
+		"Package": *pkg,
+		"Type":    *typeName,
+		"Prefix":  strings.ToLower((*typeName)[:1]) + (*typeName)[1:],
+		"Grammar": grammar,
+		"Corpus":  strconv.Quote(*corpus),
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	formatted, err := format.Source(testCode.Bytes())
+	if err != nil {
+		log.Fatalf("invalid test code: %v", err)
+	}
+
+	testFile := strings.TrimSuffix(*output, ".go") + "_test.go"
+	if err := ioutil.WriteFile(testFile, formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
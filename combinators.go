@@ -17,7 +17,7 @@ func Fail(state ParserState, err error) (*ParserResult, error) {
 
 // Expect creates a Parser that expects a single given character and if successfull returns a string as Result
 func Expect(expected rune) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindExpect, Text: string(expected)}, func(state ParserState) (*ParserResult, error) {
 		if state.CurrentRune() == 0 {
 			return Fail(state, fmt.Errorf(`Stream ended, expected "%c"`, expected))
 		}
@@ -32,13 +32,11 @@ func Expect(expected rune) Parser {
 
 // ExpectPredicate creates a Parser for a rune based on given predicate function
 func ExpectPredicate(predicate func(rune) bool, descriptions ...string) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindExpectPredicate, Text: strings.Join(descriptions, ", "), Predicate: predicate}, func(state ParserState) (*ParserResult, error) {
 		if state.CurrentRune() == 0 {
 			return Fail(state, fmt.Errorf(`Stream ended, expected "%v"`, descriptions))
 		}
 
-		// log.Printf(`predicate: "%c" is %v`, state.CurrentRune(), descriptions)
-
 		if predicate(state.CurrentRune()) {
 			return Success(state.Remaining(), string(state.CurrentRune()))
 		}
@@ -49,7 +47,7 @@ func ExpectPredicate(predicate func(rune) bool, descriptions ...string) Parser {
 
 // ExpectAny creates a Parser that expects any rune from a given list
 func ExpectAny(expectedList []rune) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindExpectAny, Text: string(expectedList)}, func(state ParserState) (*ParserResult, error) {
 		for _, expected := range expectedList {
 			if state.CurrentRune() == 0 {
 				return Fail(state, fmt.Errorf(`Stream ended, expected one of %v`, strings.Join(strings.Split(string(expectedList), ""), ", ")))
@@ -66,7 +64,7 @@ func ExpectAny(expectedList []rune) Parser {
 
 // ExpectString creates a Parser that expects all the runes from a given list
 func ExpectString(expectedList []rune) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindExpectString, Text: string(expectedList)}, func(state ParserState) (*ParserResult, error) {
 		currentState := state
 
 		for i, expected := range expectedList {
@@ -87,7 +85,7 @@ func ExpectString(expectedList []rune) Parser {
 
 // SeqOf combines parsers in a seequence
 func SeqOf(parsers ...Parser) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindSeqOf, Children: parsers}, func(state ParserState) (*ParserResult, error) {
 		currentState := state
 		results := []interface{}{}
 
@@ -122,14 +120,48 @@ func SeqIgnore(parser Parser) Parser {
 	return &seqIgnore{parser}
 }
 
-// AnyOf must match one of the given parsers
+// AnyOf must match one of the given parsers, tried in order. The alternatives
+// that by their FIRST set can't start with the current rune are skipped, and
+// only tried for their errors when all the others fail.
 func AnyOf(parsers ...Parser) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	table := &dispatchTable{}
+
+	var self Parser
+	self = newCombinator(Description{Kind: KindAnyOf, Children: parsers}, func(state ParserState) (*ParserResult, error) {
+		apply := func(i int) (*ParserResult, error) {
+			pr, err := parsers[i].Apply(state)
+			if session := sessionOf(state); session != nil && session.coverage != nil {
+				session.coverage.count(coverageKey{self, i}, err == nil)
+			}
+			return pr, err
+		}
+
+		// the alternatives that can't start with the current rune are only
+		// tried for their errors when all the others fail
+		var tried []error
+		if candidates, ok := table.lookup(self, parsers, state); ok {
+			for _, i := range candidates {
+				pr, err := apply(i)
+				if err == nil {
+					return Success(pr.Remaining, pr.Result)
+				}
+
+				if tried == nil {
+					tried = make([]error, len(parsers))
+				}
+				tried[i] = err
+			}
+		}
+
 		errors := []string{}
 
-		for _, parser := range parsers {
+		for i := range parsers {
+			if tried != nil && tried[i] != nil {
+				errors = append(errors, fmt.Sprintf(" - %v", tried[i]))
+				continue
+			}
 
-			pr, err := parser.Apply(state)
+			pr, err := apply(i)
 
 			if err == nil {
 				return Success(pr.Remaining, pr.Result)
@@ -140,11 +172,13 @@ func AnyOf(parsers ...Parser) Parser {
 
 		return Fail(state, fmt.Errorf("All cases failed:\n%s", strings.Join(errors, "\n")))
 	})
+
+	return self
 }
 
 // RepeatUntil ...
 func RepeatUntil(parser Parser, terminator Parser) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindRepeatUntil, Children: []Parser{parser, terminator}}, func(state ParserState) (*ParserResult, error) {
 		currentState := state
 		results := []interface{}{}
 
@@ -161,10 +195,8 @@ func RepeatUntil(parser Parser, terminator Parser) Parser {
 			currentState = pr.Remaining
 
 			_, err = terminator.Apply(currentState)
-			// log.Printf(`until: %+v`, err)
 		}
 
-		// log.Printf(`stopped`)
 		return Success(currentState, results)
 	})
 }
@@ -181,7 +213,7 @@ type Partial interface{}
 //
 // and the result is ["aaaa", "aaaaa", Partial("aaa"), "aaaaa", "aa"]
 func RestarableOneOrMore(parser Parser, restart Parser) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindRestarableOneOrMore, Children: []Parser{parser, restart}}, func(state ParserState) (*ParserResult, error) {
 		// currentState := state
 		// results := []interface{}{}
 
@@ -249,7 +281,7 @@ func RestarableOneOrMore(parser Parser, restart Parser) Parser {
 
 // OneOrMore matches one or more of a given parser
 func OneOrMore(parser Parser) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindOneOrMore, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
 		currentState := state
 		results := []interface{}{}
 
@@ -272,7 +304,7 @@ func OneOrMore(parser Parser) Parser {
 
 // ZeroOrMore matches zero or more of a given parser
 func ZeroOrMore(parser Parser) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindZeroOrMore, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
 		currentState := state
 		results := []interface{}{}
 
@@ -294,7 +326,7 @@ func ZeroOrMore(parser Parser) Parser {
 
 // Optional matches zero or one of a given parser
 func Optional(parser Parser) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	return newCombinator(Description{Kind: KindOptional, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
 		pr, err := parser.Apply(state)
 		if err != nil {
 			return Success(state, nil)
@@ -304,16 +336,69 @@ func Optional(parser Parser) Parser {
 	})
 }
 
+// Not succeeds without consuming anything when the given parser fails, like
+// the "!" predicate of PEGs
+func Not(parser Parser) Parser {
+	return newCombinator(Description{Kind: KindNot, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		pr, err := parser.Apply(state)
+		if err == nil {
+			return Fail(state, ErrorAt(state, fmt.Errorf(`Unexpected "%s"`, ConsumedText(state, pr.Remaining))))
+		}
+
+		return Success(state, nil)
+	})
+}
+
+// Lookahead matches a parser without consuming anything, like the "&"
+// predicate of PEGs
+func Lookahead(parser Parser) Parser {
+	return newCombinator(Description{Kind: KindLookahead, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		pr, err := parser.Apply(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return Success(state, pr.Result)
+	})
+}
+
 // Transform a parser result if successfull
 func Transform(parser Parser, transform func(interface{}) interface{}) Parser {
-	return FuncParser(func(state ParserState) (*ParserResult, error) {
+	call := func(i interface{}) (interface{}, error) {
+		return transform(i), nil
+	}
+
+	return newCombinator(Description{Kind: KindTransform, Children: []Parser{parser}, Transform: transform}, func(state ParserState) (*ParserResult, error) {
 		pr, err := parser.Apply(state)
 
 		if err != nil {
 			return Fail(state, err)
 		}
 
-		result := transform(pr.Result)
+		result, _ := applyTransform(state, call, pr.Result)
+
+		return Success(pr.Remaining, result)
+	})
+}
+
+// TransformErr transforms a parser result if successfull with a function
+// that can fail, its errors are located at the beginning of the parsed text
+func TransformErr(parser Parser, transform func(interface{}) (interface{}, error)) Parser {
+	return newCombinator(Description{Kind: KindTransformErr, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		pr, err := parser.Apply(state)
+
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		result, err := applyTransform(state, transform, pr.Result)
+		if err != nil {
+			if _, ok := err.(*ParseError); !ok {
+				err = ErrorAt(state, err)
+			}
+
+			return Fail(state, err)
+		}
 
 		return Success(pr.Remaining, result)
 	})
@@ -321,7 +406,23 @@ func Transform(parser Parser, transform func(interface{}) interface{}) Parser {
 
 // StringifyResult ...
 func StringifyResult(parser Parser) Parser {
-	return Transform(parser, func(i interface{}) interface{} {
+	return namedTransform("StringifyResult", parser, func(i interface{}) interface{} {
 		return StringifyInterfaces(i)
 	})
 }
+
+// Unwrap transforms a []interface{} result with a single item in that item,
+// like the result of a SeqOf where all the parsers but one are ignored
+func Unwrap(parser Parser) Parser {
+	return namedTransform("Unwrap", parser, func(i interface{}) interface{} {
+		return i.([]interface{})[0]
+	})
+}
+
+// namedTransform is a Transform whose Description has the given Text, so that
+// tools can recognize the standard transforms
+func namedTransform(name string, parser Parser, transform func(interface{}) interface{}) Parser {
+	t := Transform(parser, transform).(*combinator)
+	t.desc.Text = name
+	return t
+}
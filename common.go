@@ -9,7 +9,7 @@ import (
 var Any = ExpectPredicate(func(r rune) bool { return true }, `any`)
 
 // EOF ...
-var EOF = FuncParser(func(state ParserState) (*ParserResult, error) {
+var EOF = newCombinator(Description{Kind: KindEOF}, func(state ParserState) (*ParserResult, error) {
 	if state.CurrentRune() == 0 {
 		return Success(state.Remaining(), string(state.CurrentRune()))
 	}
@@ -0,0 +1,71 @@
+package combinators
+
+// Coverage counts how many times the Named rules and the alternatives of the
+// AnyOf parsers of a grammar are attempted and succeed across the parses
+// using it, see WithCoverage, to find the parts of a grammar a test corpus
+// doesn't exercise. Compiled Programs are interpreted to be counted, the
+// results cached by Memoize and reused by an IncrementalParser are not
+// counted again. A Coverage must not be shared by concurrent parses.
+type Coverage struct {
+	counts map[coverageKey]*CoverageCount
+}
+
+// CoverageCount is the number of times a rule or an alternative was attempted and succeeded
+type CoverageCount struct {
+	Attempts  int
+	Successes int
+}
+
+// coverageKey identifies a Named rule, with index -1, or an alternative of an AnyOf
+type coverageKey struct {
+	parser Parser
+	index  int
+}
+
+// NewCoverage creates an empty Coverage
+func NewCoverage() *Coverage {
+	return &Coverage{counts: map[coverageKey]*CoverageCount{}}
+}
+
+// WithCoverage counts the rules and alternatives of the parse in the given Coverage
+func WithCoverage(coverage *Coverage) ParseOption {
+	return func(session *parseSession) {
+		session.coverage = coverage
+	}
+}
+
+func (cov *Coverage) count(key coverageKey, success bool) {
+	count, ok := cov.counts[key]
+	if !ok {
+		count = &CoverageCount{}
+		cov.counts[key] = count
+	}
+
+	count.Attempts++
+	if success {
+		count.Successes++
+	}
+}
+
+func (cov *Coverage) get(key coverageKey) CoverageCount {
+	if count, ok := cov.counts[key]; ok {
+		return *count
+	}
+
+	return CoverageCount{}
+}
+
+// Rule returns the counts of a Named rule
+func (cov *Coverage) Rule(rule Parser) CoverageCount {
+	return cov.get(coverageKey{rule, -1})
+}
+
+// Alternative returns the counts of the alternative of an AnyOf with the given index
+func (cov *Coverage) Alternative(anyOf Parser, index int) CoverageCount {
+	return cov.get(coverageKey{anyOf, index})
+}
+
+// Reset discards everything counted so far
+func (cov *Coverage) Reset() {
+	cov.counts = map[coverageKey]*CoverageCount{}
+}
@@ -0,0 +1,43 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverage(t *testing.T) {
+	digit := Named("Digit", Digit)
+	letter := Named("Letter", Letter)
+	choice := AnyOf(digit, letter, Expect('_'))
+	parser := Named("Chars", OneOrMore(choice))
+
+	cov := NewCoverage()
+	for _, input := range []string{"1a", "2"} {
+		_, err := ParseRuneReader(parser, strings.NewReader(input), WithCoverage(cov))
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, CoverageCount{Attempts: 2, Successes: 2}, cov.Rule(parser))
+	// alternatives that can't start with the next rune are skipped, not attempted
+	assert.Equal(t, CoverageCount{Attempts: 4, Successes: 2}, cov.Rule(digit))
+	assert.Equal(t, CoverageCount{Attempts: 3, Successes: 1}, cov.Rule(letter))
+	assert.Equal(t, CoverageCount{Attempts: 4, Successes: 2}, cov.Alternative(choice, 0))
+	assert.Equal(t, CoverageCount{Attempts: 3, Successes: 1}, cov.Alternative(choice, 1))
+	assert.Equal(t, CoverageCount{Attempts: 2, Successes: 0}, cov.Alternative(choice, 2))
+	assert.Equal(t, CoverageCount{}, cov.Alternative(choice, 3))
+
+	{
+		// compiled programs are counted the same
+		compiled := NewCoverage()
+		for _, input := range []string{"1a", "2"} {
+			_, err := ParseRuneReader(Compile(parser), strings.NewReader(input), WithCoverage(compiled))
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, cov, compiled)
+	}
+
+	cov.Reset()
+	assert.Equal(t, CoverageCount{}, cov.Rule(parser))
+}
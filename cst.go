@@ -0,0 +1,303 @@
+package combinators
+
+import (
+	"io"
+	"strings"
+)
+
+// CSTNode is a node of the lossless concrete syntax tree built by ParseCST.
+// Rule nodes are the Named rules that matched, tokens are the leaves matched
+// by terminal parsers and Lexemes. The whitespace and comments skipped by the
+// Trivia of a Skipper and the indentation consumed by SameIndent and
+// IndentGreater are kept as the Leading and Trailing trivia of the tokens.
+type CSTNode struct {
+	// Kind is the name of a rule, or the description of the parser of a
+	// token like `Expect('(')`. The input consumed by parsers without a
+	// Description, like FuncParsers, is kept in tokens of KindOpaque.
+	Kind string
+	// Token tells the node is a leaf
+	Token bool
+	// Start and End are the offsets of the input consumed by the node, for
+	// tokens without their trivia
+	Start, End int
+	Children   []*CSTNode
+	// Text is the text of a token
+	Text string
+	// Leading and Trailing are the trivia before and after a token
+	Leading, Trailing string
+	// Result is the semantic result of the parser of the node
+	Result interface{}
+	// Label tells the node is the span of a Label, with the label as Kind,
+	// they are only recorded with WithCSTLabels
+	Label bool
+
+	// trivia marks the trivia not yet attached to a token
+	trivia bool
+}
+
+// Tokens returns the tokens of the subtree in order
+func (n *CSTNode) Tokens() []*CSTNode {
+	if n.Token {
+		return []*CSTNode{n}
+	}
+
+	tokens := []*CSTNode{}
+	for _, child := range n.Children {
+		tokens = append(tokens, child.Tokens()...)
+	}
+
+	return tokens
+}
+
+// Source returns the text of the subtree with all its trivia, for the root
+// of a ParseCST it is exactly the input consumed by the parser
+func (n *CSTNode) Source() string {
+	var sb strings.Builder
+	for _, token := range n.Tokens() {
+		sb.WriteString(token.Leading)
+		sb.WriteString(token.Text)
+		sb.WriteString(token.Trailing)
+	}
+
+	return sb.String()
+}
+
+// WithCSTLabels makes ParseCST record the spans of the Labels that matched as
+// nodes with the label as Kind, for example to highlight the input
+func WithCSTLabels() ParseOption {
+	return func(session *parseSession) {
+		session.cstLabels = true
+	}
+}
+
+// ParseCST parses like ParseRuneReader and also returns the concrete syntax
+// tree of the consumed input, use EOF to require all of it. Compiled Programs
+// are interpreted and Memoize doesn't cache while building the tree.
+func ParseCST(parser Parser, r io.RuneReader, opts ...ParseOption) (interface{}, *CSTNode, error) {
+	session := newParseSession(opts)
+	b := &cstBuilder{labels: session.cstLabels}
+	session.cst = b
+
+	result, state, err := parseRunes(parser, r, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	end := 0
+	if state != nil {
+		end = b.offset(state)
+	}
+
+	root := &CSTNode{Start: 0, End: end, Result: result}
+	root.Children = b.complete(b.top, 0, end, false)
+	attachTrivia(root)
+
+	return result, root, nil
+}
+
+// cstBuilder collects the nodes of the successful parsers during a parse
+type cstBuilder struct {
+	input *runeInput
+	// open are the nodes collected by the parsers being applied
+	open [][]*CSTNode
+	top  []*CSTNode
+	// labels records the spans of Labels as nodes
+	labels bool
+	// lexemes counts the Lexemes being applied, the parsers inside them are not recorded
+	lexemes int
+	// trivia is the span of the last Trivia applied inside a Lexeme
+	triviaStart, triviaEnd int
+}
+
+// isCSTToken tells if a kind of parser matches a token
+func isCSTToken(kind Kind) bool {
+	switch kind {
+	case KindExpect, KindExpectPredicate, KindExpectAny, KindExpectString, KindQuotedString,
+		KindExpectToken, KindExpectTokenText:
+		return true
+	}
+
+	return false
+}
+
+func (b *cstBuilder) offset(state ParserState) int {
+	s := state.(*RuneScanner)
+	b.input = s.input
+
+	if s.cursor > len(s.input.buffer) {
+		return len(s.input.buffer)
+	}
+
+	return s.cursor
+}
+
+func (b *cstBuilder) text(start, end int) string {
+	return string(b.input.buffer[start:end])
+}
+
+func (b *cstBuilder) add(node *CSTNode) {
+	if len(b.open) == 0 {
+		b.top = append(b.top, node)
+		return
+	}
+
+	b.open[len(b.open)-1] = append(b.open[len(b.open)-1], node)
+}
+
+// record applies a combinator and adds the nodes of its match to the parent
+func (b *cstBuilder) record(p *combinator, state ParserState, fn FuncParser) (*ParserResult, error) {
+	kind := p.desc.Kind
+
+	if b.lexemes > 0 || kind == KindLexeme {
+		if b.lexemes == 0 {
+			b.triviaStart, b.triviaEnd = -1, -1
+		}
+
+		b.lexemes++
+		pr, err := fn(state)
+		b.lexemes--
+
+		if err != nil {
+			return pr, err
+		}
+
+		start, end := b.offset(state), b.offset(pr.Remaining)
+		switch {
+		case kind == KindTrivia:
+			b.triviaStart, b.triviaEnd = start, end
+		case kind == KindLexeme && b.lexemes == 0:
+			b.addLexeme(p, start, end, pr.Result)
+		}
+
+		return pr, nil
+	}
+
+	b.open = append(b.open, nil)
+	pr, err := fn(state)
+	children := b.open[len(b.open)-1]
+	b.open = b.open[:len(b.open)-1]
+
+	if err != nil {
+		return pr, err
+	}
+
+	start, end := b.offset(state), b.offset(pr.Remaining)
+
+	switch {
+	case kind == KindNamed:
+		b.add(&CSTNode{Kind: p.desc.Label, Start: start, End: end, Children: b.complete(children, start, end, false), Result: pr.Result})
+	case kind == KindTrivia:
+		if start < end {
+			b.add(&CSTNode{Kind: string(kind), Start: start, End: end, Text: b.text(start, end), trivia: true})
+		}
+	case kind == KindLabel && b.labels:
+		b.add(&CSTNode{Kind: p.desc.Label, Start: start, End: end, Children: b.complete(children, start, end, false), Result: pr.Result, Label: true})
+	case isCSTToken(kind):
+		if start < end {
+			b.add(&CSTNode{Kind: p.name, Token: true, Start: start, End: end, Text: b.text(start, end), Result: pr.Result})
+		}
+	default:
+		indentation := kind == KindSameIndent || kind == KindIndentGreater
+		for _, child := range b.complete(children, start, end, indentation) {
+			b.add(child)
+		}
+	}
+
+	return pr, nil
+}
+
+// addLexeme adds the token of a Lexeme, with the trivia it skipped as trailing
+func (b *cstBuilder) addLexeme(p *combinator, start, end int, result interface{}) {
+	tokenEnd := end
+	if b.triviaEnd == end && b.triviaStart >= start {
+		tokenEnd = b.triviaStart
+	}
+
+	kind := string(KindLexeme)
+	if desc := Describe(p.desc.Children[0]); desc.Kind == KindNamed || isCSTToken(desc.Kind) {
+		kind = desc.String()
+	}
+
+	token := &CSTNode{Kind: kind, Token: true, Start: start, End: tokenEnd, Result: result}
+	token.Text = b.text(start, tokenEnd)
+	token.Trailing = b.text(tokenEnd, end)
+
+	if start < end {
+		b.add(token)
+	}
+}
+
+// complete returns the nodes that are part of a match between start and
+// end, dropping the ones discarded by lookaheads and repetitions, and fills
+// the gaps between them with tokens, or trivia for indentation
+func (b *cstBuilder) complete(nodes []*CSTNode, start, end int, indentation bool) []*CSTNode {
+	completed := []*CSTNode{}
+	pos := start
+
+	gap := func(gapEnd int) {
+		if pos >= gapEnd {
+			return
+		}
+
+		text := b.text(pos, gapEnd)
+		if indentation {
+			completed = append(completed, &CSTNode{Kind: string(KindTrivia), Start: pos, End: gapEnd, Text: text, trivia: true})
+		} else {
+			completed = append(completed, &CSTNode{Kind: string(KindOpaque), Token: true, Start: pos, End: gapEnd, Text: text})
+		}
+	}
+
+	for _, node := range nodes {
+		nodeEnd := node.End + len([]rune(node.Trailing))
+		if node.Start < pos || nodeEnd > end {
+			continue
+		}
+
+		gap(node.Start)
+		completed = append(completed, node)
+		pos = nodeEnd
+	}
+	gap(end)
+
+	return completed
+}
+
+// attachTrivia moves the trivia nodes to the Leading of the next token, or
+// to the Trailing of the last one
+func attachTrivia(root *CSTNode) {
+	var last *CSTNode
+	pending := ""
+
+	var walk func(n *CSTNode)
+	walk = func(n *CSTNode) {
+		children := []*CSTNode{}
+
+		for _, child := range n.Children {
+			switch {
+			case child.trivia:
+				pending += child.Text
+				continue
+			case child.Token:
+				child.Leading, pending = pending+child.Leading, ""
+				last = child
+			default:
+				walk(child)
+			}
+
+			children = append(children, child)
+		}
+
+		n.Children = children
+	}
+	walk(root)
+
+	if pending == "" {
+		return
+	}
+
+	if last == nil {
+		last = &CSTNode{Kind: string(KindTrivia), Token: true, Start: root.End, End: root.End}
+		root.Children = append(root.Children, last)
+	}
+	last.Trailing += pending
+}
@@ -0,0 +1,76 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cstTokens describes the tokens of a tree as their leading trivia, text and trailing trivia
+func cstTokens(root *CSTNode) [][3]string {
+	tokens := [][3]string{}
+	for _, token := range root.Tokens() {
+		tokens = append(tokens, [3]string{token.Leading, token.Text, token.Trailing})
+	}
+	return tokens
+}
+
+func TestCST(t *testing.T) {
+	{
+		skipper := &Skipper{LineComments: []string{"#"}}
+		number := Named("Number", skipper.Lexeme(StringifyResult(OneOrMore(Digit))))
+		sum := Named("Sum", SeqOf(SeqIgnore(skipper.Trivia()), number, SeqIgnore(skipper.Symbol("+")), number, EOF))
+
+		input := " 1 # one\n+ 2 "
+		r, root, err := ParseCST(sum, strings.NewReader(input))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"1", "2", "\x00"}, r)
+		assert.Equal(t, input, root.Source())
+		assert.Equal(t, [][3]string{{" ", "1", " # one\n"}, {"", "+", " "}, {"", "2", " "}}, cstTokens(root))
+
+		rule := root.Children[0]
+		assert.Equal(t, "Sum", rule.Kind)
+		assert.Equal(t, []interface{}{"1", "2", "\x00"}, rule.Result)
+		assert.Equal(t, []string{"Number", `ExpectString("+")`, "Number"}, []string{rule.Children[0].Kind, rule.Children[1].Kind, rule.Children[2].Kind})
+		assert.Equal(t, &CSTNode{Kind: "Lexeme", Token: true, Start: 1, End: 2, Text: "1", Leading: " ", Trailing: " # one\n", Result: "1"}, rule.Children[0].Children[0])
+	}
+	{
+		// indentation is trivia
+		input := " a\n  b\n c\n"
+		_, root, err := ParseCST(tree, strings.NewReader(input))
+		assert.Nil(t, err)
+		assert.Equal(t, input, root.Source())
+		assert.Equal(t, [][3]string{{" ", "a", ""}, {"", "\n", ""}, {"  ", "b", ""}, {"", "\n", ""}, {" ", "c", ""}, {"", "\n", ""}}, cstTokens(root))
+	}
+	{
+		input := "(1+2)*3"
+		_, root, err := ParseCST(Compile(expr), strings.NewReader(input))
+		assert.Nil(t, err)
+		assert.Equal(t, input, root.Source())
+
+		product := root.Children[0].Children[0]
+		assert.Equal(t, "Product", product.Kind)
+		assert.Equal(t, []string{"Value", `ExpectAny("*/")`, "Value"}, []string{product.Children[0].Kind, product.Children[1].Kind, product.Children[2].Kind})
+		assert.Equal(t, 6, product.Children[2].Start)
+	}
+	{
+		// lookaheads and terminators are not part of the tree, opaque parsers are kept as text
+		opaque := FuncParser(func(state ParserState) (*ParserResult, error) {
+			return Success(state.Remaining().Remaining(), nil)
+		})
+		parser := SeqOf(Lookahead(Expect('a')), RepeatUntil(Any, Expect(';')), Expect(';'), opaque, Optional(Expect('x')))
+
+		input := "ab;cdx"
+		_, root, err := ParseCST(parser, strings.NewReader(input+"yz"))
+		assert.Nil(t, err)
+		assert.Equal(t, input, root.Source())
+		assert.Equal(t, [][3]string{{"", "a", ""}, {"", "b", ""}, {"", ";", ""}, {"", "cd", ""}, {"", "x", ""}}, cstTokens(root))
+		assert.Equal(t, "Opaque", root.Children[3].Kind)
+	}
+	{
+		_, root, err := ParseCST(Expect('a'), strings.NewReader("b"))
+		assert.EqualError(t, err, `Expected "a"`)
+		assert.Nil(t, root)
+	}
+}
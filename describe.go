@@ -0,0 +1,144 @@
+package combinators
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Kind identifies the combinator that built a Parser, its value is the name
+// of the constructor
+type Kind string
+
+// Kinds of the built-in combinators
+const (
+	KindOpaque Kind = "Opaque"
+
+	KindExpect          Kind = "Expect"
+	KindExpectPredicate Kind = "ExpectPredicate"
+	KindExpectAny       Kind = "ExpectAny"
+	KindExpectString    Kind = "ExpectString"
+	KindEOF             Kind = "EOF"
+
+	KindSeqOf               Kind = "SeqOf"
+	KindSeqIgnore           Kind = "SeqIgnore"
+	KindAnyOf               Kind = "AnyOf"
+	KindRepeatUntil         Kind = "RepeatUntil"
+	KindRestarableOneOrMore Kind = "RestarableOneOrMore"
+	KindOneOrMore           Kind = "OneOrMore"
+	KindZeroOrMore          Kind = "ZeroOrMore"
+	KindOptional            Kind = "Optional"
+	KindNot                 Kind = "Not"
+	KindLookahead           Kind = "Lookahead"
+	KindTransform           Kind = "Transform"
+	KindTransformErr        Kind = "TransformErr"
+	KindLazy                Kind = "Lazy"
+	KindMemoize             Kind = "Memoize"
+
+	KindNamed Kind = "Named"
+	KindLabel Kind = "Label"
+
+	KindQuotedString Kind = "QuotedString"
+	KindTrivia       Kind = "Trivia"
+	KindLexeme       Kind = "Lexeme"
+	KindVerbatim     Kind = "Verbatim"
+
+	KindExpectToken     Kind = "ExpectToken"
+	KindExpectTokenText Kind = "ExpectTokenText"
+
+	KindIndented      Kind = "Indented"
+	KindSameIndent    Kind = "SameIndent"
+	KindIndentGreater Kind = "IndentGreater"
+
+	KindGetState    Kind = "GetState"
+	KindSetState    Kind = "SetState"
+	KindUpdateState Kind = "UpdateState"
+	KindCheckState  Kind = "CheckState"
+	KindFromState   Kind = "FromState"
+)
+
+// Description is the reflective description of a Parser
+type Description struct {
+	Kind Kind
+	// Label is the name of a Named rule or the description of a Label
+	Label string
+	// Text is the literal text of Expect, ExpectAny, ExpectString and
+	// ExpectTokenText, a human readable description of other terminals or
+	// the name of the standard transforms StringifyResult and Unwrap
+	Text string
+	// Predicate is the rune predicate of ExpectPredicate
+	Predicate func(rune) bool
+	// Class is the character class of the ExpectPredicate parsers built by ExpectClass
+	Class *CharClass
+	// Transform is the function of Transform
+	Transform func(interface{}) interface{}
+	// Children are the sub parsers, in order
+	Children []Parser
+}
+
+// String returns a short description like "Expect('a')" or the name of a Named rule
+func (d Description) String() string {
+	switch d.Kind {
+	case KindNamed, KindLabel:
+		return d.Label
+	case KindExpect:
+		return fmt.Sprintf("%s(%q)", d.Kind, []rune(d.Text)[0])
+	case KindExpectAny, KindExpectString, KindExpectTokenText:
+		return fmt.Sprintf("%s(%q)", d.Kind, d.Text)
+	case KindExpectPredicate, KindQuotedString, KindExpectToken, KindOpaque:
+		return fmt.Sprintf("%s(%s)", d.Kind, d.Text)
+	}
+
+	return string(d.Kind)
+}
+
+// Describable is implemented by parsers that can describe themselves, all the
+// built-in combinators do
+type Describable interface {
+	Describe() Description
+}
+
+// Describe returns the Description of a parser, parsers that are not
+// Describable (like FuncParsers) are reported as KindOpaque
+func Describe(parser Parser) Description {
+	if d, ok := parser.(Describable); ok {
+		return d.Describe()
+	}
+
+	return Description{Kind: KindOpaque, Text: fmt.Sprintf("%T", parser)}
+}
+
+// Describe - see Describable
+func (p *seqIgnore) Describe() Description {
+	return Description{Kind: KindSeqIgnore, Children: []Parser{p.Parser}}
+}
+
+type lazy struct {
+	once   sync.Once
+	get    func() Parser
+	parser Parser
+}
+
+// Lazy creates a Parser that gets the actual parser on first use, this allows
+// recursive grammars to reference rules that are not yet defined while staying
+// introspectable
+func Lazy(get func() Parser) Parser {
+	return &lazy{get: get}
+}
+
+func (p *lazy) resolve() Parser {
+	p.once.Do(func() {
+		p.parser = p.get()
+	})
+
+	return p.parser
+}
+
+// Apply - trivial
+func (p *lazy) Apply(state ParserState) (*ParserResult, error) {
+	return p.resolve().Apply(state)
+}
+
+// Describe - see Describable
+func (p *lazy) Describe() Description {
+	return Description{Kind: KindLazy, Children: []Parser{p.resolve()}}
+}
@@ -0,0 +1,70 @@
+package combinators
+
+import (
+	"sync"
+)
+
+// dispatchTable maps the ASCII runes, and zero for the end of the input, to
+// the alternatives of an AnyOf that can match them. It is built on the first
+// use as Lazy parsers can only be resolved once the grammar is complete.
+type dispatchTable struct {
+	once sync.Once
+	// candidates are the indices of the alternatives, in order, that can
+	// succeed at each rune, it is nil if no alternative can ever be skipped
+	candidates *[128][]int
+}
+
+func (t *dispatchTable) build(parser Parser, alternatives []Parser) {
+	a := analyzeFirst(parser)
+
+	firsts := []FirstSet{}
+	for _, alternative := range alternatives {
+		firsts = append(firsts, a.First(alternative))
+	}
+
+	candidates := [128][]int{}
+	skips := false
+
+	for r := range candidates {
+		for i, alternative := range alternatives {
+			if a.Nullable(alternative) || firsts[i].Matches(rune(r)) {
+				candidates[r] = append(candidates[r], i)
+			}
+		}
+
+		skips = skips || len(candidates[r]) < len(alternatives)
+	}
+
+	if skips {
+		t.candidates = &candidates
+	}
+}
+
+// lookup returns the alternatives to try at the current rune of a state, or
+// false if all of them must be tried
+func (t *dispatchTable) lookup(parser Parser, alternatives []Parser, state ParserState) ([]int, bool) {
+	t.once.Do(func() {
+		t.build(parser, alternatives)
+	})
+
+	r := state.CurrentRune()
+	if t.candidates == nil || r < 0 || int(r) >= len(t.candidates) {
+		return nil, false
+	}
+
+	// traces record every alternative tried by plain ordered choice
+	if session := sessionOf(state); session != nil && (session.tracer != nil || session.noDispatch) {
+		return nil, false
+	}
+
+	return t.candidates[r], true
+}
+
+// WithoutDispatch disables the first character dispatch of AnyOf, that only
+// tries the alternatives whose FIRST set contains the current rune, to compare
+// it with plain ordered choice
+func WithoutDispatch() ParseOption {
+	return func(session *parseSession) {
+		session.noDispatch = true
+	}
+}
@@ -0,0 +1,82 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertDispatchAgrees checks that AnyOf has the same results and errors
+// with and without the first character dispatch on each input
+func assertDispatchAgrees(t *testing.T, parser Parser, inputs []string) {
+	for _, input := range inputs {
+		expected, expectedErr := ParseRuneReader(parser, strings.NewReader(input), WithoutDispatch())
+		actual, actualErr := ParseRuneReader(parser, strings.NewReader(input))
+
+		assert.Equal(t, expected, actual, "input %q", input)
+		if expectedErr == nil {
+			assert.Nil(t, actualErr, "input %q", input)
+		} else {
+			assert.EqualError(t, actualErr, expectedErr.Error(), "input %q", input)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	assertDispatchAgrees(t, SeqOf(expr, EOF), []string{
+		"1", "1+2*3", "(1+2)*(3-4)/5", "((1))", "", "1+", "(1+2", "1+2)", "1*(2+x)", "12a",
+	})
+
+	// ordered choice between overlapping and nullable alternatives
+	assertDispatchAgrees(t, SeqOf(
+		AnyOf(
+			ExpectString([]rune("ab")),
+			SeqOf(Expect('a'), Optional(Expect('c'))),
+			SeqOf(Optional(Expect('x')), Expect('y')),
+			SeqOf(Not(Expect('b')), ExpectPredicate(unicode.IsUpper, "uppercase")),
+			EOF,
+		),
+		ZeroOrMore(Any),
+	), []string{"ab", "ac", "a", "xy", "y", "Q", "b", "z", "", "è"})
+
+	assertDispatchAgrees(t, tree, []string{" a\n  b\n c\n\t d\n e\n", "a\n b\n   c\n  d\n", "a\n  b\n c\n"})
+
+	skipper := &Skipper{LineComments: []string{"#"}}
+	value := AnyOf(skipper.Lexeme(StringifyResult(OneOrMore(Digit))), skipper.Symbol("nil"), skipper.Symbol("("))
+	assertDispatchAgrees(t, SeqOf(SeqIgnore(skipper.Trivia()), OneOrMore(value), EOF), []string{
+		"1 nil", " 1 # one\n( 2 ", "ni", "1 x",
+	})
+}
+
+func TestDispatchSkips(t *testing.T) {
+	calls := 0
+	digit := ExpectPredicate(func(r rune) bool {
+		calls++
+		return unicode.IsDigit(r)
+	}, "digit")
+	parser := AnyOf(digit, Expect('a'))
+
+	// the first parse builds the table
+	ParseRuneReader(parser, strings.NewReader("1"))
+
+	{
+		calls = 0
+		r, err := ParseRuneReader(parser, strings.NewReader("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, "a", r)
+		assert.Equal(t, 0, calls)
+	}
+	{
+		calls = 0
+		r, err := ParseRuneReader(parser, strings.NewReader("a"), WithoutDispatch())
+		assert.Nil(t, err)
+		assert.Equal(t, "a", r)
+		assert.Equal(t, 1, calls)
+	}
+	{
+		_, err := ParseRuneReader(parser, strings.NewReader("b"))
+		assert.EqualError(t, err, "All cases failed:\n - Expected \"[digit]\"\n - Expected \"a\"")
+	}
+}
@@ -0,0 +1,130 @@
+// Package arith evaluates arithmetic expressions on integers with a parser
+// generated from the PEG grammar in arith.peg
+package arith
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//go:generate go run github.com/aziis98/parser-combinators/cmd/parcomb-gen -peg arith.peg -test
+
+// Eval evaluates an expression, undefined variables are zero
+func Eval(expr string, vars map[string]int) (int, error) {
+	e := &evaluator{vars: vars}
+
+	result, err := (&Parser{Actions: e.actions()}).Parse(expr)
+	if err != nil {
+		return 0, err
+	}
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	return result.(int), nil
+}
+
+// evaluator holds the variables of an evaluation and its first error, as
+// actions can't fail
+type evaluator struct {
+	vars map[string]int
+	err  error
+}
+
+// actions are the semantic actions of the grammar, they work both with the
+// generated parser and with the grammar loaded by package peg
+func (e *evaluator) actions() map[string]func(interface{}) interface{} {
+	return map[string]func(interface{}) interface{}{
+		"Expr":    func(i interface{}) interface{} { return i.([]interface{})[1] },
+		"Sum":     e.fold,
+		"Product": e.fold,
+		"Power": func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			if seq[1] == nil {
+				return seq[0]
+			}
+
+			base, exponent := seq[0].(int), seq[1].([]interface{})[2].(int)
+			result := 1
+			for n := 0; n < exponent; n++ {
+				result *= base
+			}
+			return result
+		},
+		"Value": func(i interface{}) interface{} {
+			switch v := i.(type) {
+			case int:
+				return v
+			case string:
+				return e.vars[v]
+			}
+
+			seq := i.([]interface{})
+			if seq[0] == "-" {
+				return -seq[2].(int)
+			}
+			return seq[2]
+		},
+		"Number": func(i interface{}) interface{} {
+			digits := ""
+			for _, digit := range i.([]interface{})[0].([]interface{}) {
+				digits += digit.(string)
+			}
+
+			n, err := strconv.Atoi(digits)
+			if err != nil && e.err == nil {
+				e.err = err
+			}
+			return n
+		},
+		"Variable": func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			name := seq[0].(string)
+			for _, r := range seq[1].([]interface{}) {
+				name += r.(string)
+			}
+			return name
+		},
+		"AddOp": first,
+		"MulOp": first,
+	}
+}
+
+func first(i interface{}) interface{} {
+	return i.([]interface{})[0]
+}
+
+// fold applies the operators of a sum or a product from left to right
+func (e *evaluator) fold(i interface{}) interface{} {
+	seq := i.([]interface{})
+	result := seq[0].(int)
+
+	for _, iOp := range seq[1].([]interface{}) {
+		op := iOp.([]interface{})
+		value := op[1].(int)
+
+		switch op[0] {
+		case "+":
+			result += value
+		case "-":
+			result -= value
+		case "*":
+			result *= value
+		case "/", "%":
+			if value == 0 {
+				if e.err == nil {
+					e.err = fmt.Errorf(`Division by zero`)
+				}
+				return 0
+			}
+
+			if op[0] == "/" {
+				result /= value
+			} else {
+				result %= value
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,956 @@
+// Code generated by parcomb-gen from arith.peg. DO NOT EDIT.
+
+package arith
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Parser is a generated recursive descent parser, its results and errors are
+// the same as the ones of the grammar it was generated from.
+type Parser struct {
+	// Actions are semantic actions by rule name, each one transforms the
+	// result of its rule.
+	Actions map[string]func(interface{}) interface{}
+}
+
+// Parse parses the input with the start rule
+func (p *Parser) Parse(input string) (interface{}, error) {
+	result, _, err := p.newState(input).ruleExpr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ParseRule parses the input with the given rule
+func (p *Parser) ParseRule(rule, input string) (interface{}, error) {
+	s := p.newState(input)
+
+	var parse func(int) (interface{}, int, error)
+	switch rule {
+	case "Expr":
+		parse = s.ruleExpr
+	case "_":
+		parse = s.rule_
+	case "Sum":
+		parse = s.ruleSum
+	case "Product":
+		parse = s.ruleProduct
+	case "Power":
+		parse = s.rulePower
+	case "Value":
+		parse = s.ruleValue
+	case "Number":
+		parse = s.ruleNumber
+	case "Letter":
+		parse = s.ruleLetter
+	case "Variable":
+		parse = s.ruleVariable
+	case "MulOp":
+		parse = s.ruleMulOp
+	case "AddOp":
+		parse = s.ruleAddOp
+
+	default:
+		return nil, fmt.Errorf(`Unknown rule "%s"`, rule)
+	}
+
+	result, _, err := parse(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+var parserRuleNames = [11]string{"Expr", "_", "Sum", "Product", "Power", "Value", "Number", "Letter", "Variable", "MulOp", "AddOp"}
+
+// parserState is the state of a single parse, its methods parse a part of the
+// grammar at the given offset and return its result, the offset after it and
+// an error. On failure the offset is the one the grammar would report.
+type parserState struct {
+	input   []rune
+	actions [11]func(interface{}) interface{}
+}
+
+func (p *Parser) newState(input string) *parserState {
+	s := &parserState{input: []rune(input)}
+	for i, name := range parserRuleNames {
+		s.actions[i] = p.Actions[name]
+	}
+
+	return s
+}
+
+// at returns the rune at the given offset, or 0 at the end of the input
+func (s *parserState) at(pos int) rune {
+	if pos < len(s.input) {
+		return s.input[pos]
+	}
+
+	return 0
+}
+
+// text returns the input between two offsets
+func (s *parserState) text(from, to int) string {
+	if to > len(s.input) {
+		to = len(s.input)
+	}
+	if from > to {
+		return ""
+	}
+
+	return string(s.input[from:to])
+}
+
+func (s *parserState) errorAt(pos int, err error) error {
+	return &ParseError{Offset: pos, Err: err, input: s.input}
+}
+
+// withRule returns a copy of the error with the given rule on top of its rule stack
+func (s *parserState) withRule(pos int, err error, rule string) error {
+	pe, ok := err.(*ParseError)
+	if !ok {
+		pe = &ParseError{Offset: pos, Err: err, input: s.input}
+	}
+
+	located := *pe
+	located.Rules = append([]string{rule}, pe.Rules...)
+	return &located
+}
+
+// ParseError is a syntax error with its position
+type ParseError struct {
+	Offset int
+	Err    error
+	// Rules is the stack of rules the error happened in, outermost first
+	Rules []string
+
+	input []rune
+}
+
+// Location returns the one based line and column of the error
+func (e *ParseError) Location() (int, int) {
+	end := e.Offset
+	if end > len(e.input) {
+		end = len(e.input)
+	}
+
+	line, start := 1, 0
+	for i, r := range e.input[:end] {
+		if r == '\n' {
+			line, start = line+1, i+1
+		}
+	}
+
+	return line, end - start + 1
+}
+
+func (e *ParseError) Error() string {
+	line, col := e.Location()
+	msg := fmt.Sprintf("%v at %d:%d", e.Err, line, col)
+
+	if len(e.Rules) > 0 {
+		msg += fmt.Sprintf(" (in %s)", strings.Join(e.Rules, " > "))
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parserAllFailed are the errors of the alternatives of a choice
+type parserAllFailed []error
+
+func (errs parserAllFailed) Error() string {
+	lines := []string{}
+	for _, err := range errs {
+		lines = append(lines, fmt.Sprintf(" - %v", err))
+	}
+
+	return fmt.Sprintf("All cases failed:\n%s", strings.Join(lines, "\n"))
+}
+
+// parserUnexpected is the error of a negative lookahead that matched some text
+type parserUnexpected string
+
+func (text parserUnexpected) Error() string {
+	return fmt.Sprintf(`Unexpected "%s"`, string(text))
+}
+
+func parserStringify(i interface{}) string {
+	switch v := i.(type) {
+	case []interface{}:
+		str := ""
+		for _, vv := range v {
+			str += parserStringify(vv)
+		}
+		return str
+	case string:
+		return v
+	}
+
+	return fmt.Sprint(i)
+}
+
+var parserErr0 = errors.New("Stream ended, expected one of  , \t, \n")
+var parserErr1 = errors.New("Expected one of  , \t, \n")
+var parserErr2 = errors.New("Stream ended, expected \"[[0-9]]\"")
+var parserErr3 = errors.New("Expected \"[[0-9]]\"")
+var parserErr4 = errors.New("Stream ended, expected \"[[a-zA-Z_]]\"")
+var parserErr5 = errors.New("Expected \"[[a-zA-Z_]]\"")
+var parserErr6 = errors.New("Stream ended, expected \"(\"")
+var parserErr7 = errors.New("Expected \"(\"")
+var parserErr8 = errors.New("Stream ended, expected \")\"")
+var parserErr9 = errors.New("Expected \")\"")
+var parserErr10 = errors.New("Stream ended, expected \"-\"")
+var parserErr11 = errors.New("Expected \"-\"")
+var parserErr12 = errors.New("Stream ended, expected \"^\"")
+var parserErr13 = errors.New("Expected \"^\"")
+var parserErr14 = errors.New("Stream ended, expected one of *, /, %")
+var parserErr15 = errors.New("Expected one of *, /, %")
+var parserErr16 = errors.New("Stream ended, expected one of +, -")
+var parserErr17 = errors.New("Expected one of +, -")
+var parserErr18 = errors.New("Stream ended, expected \"[any]\"")
+
+// ruleExpr is Expr
+func (s *parserState) ruleExpr(pos int) (interface{}, int, error) {
+	result, next, err := s.node1(pos)
+	action := s.actions[0]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Expr")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node1 is SeqOf in Expr
+func (s *parserState) node1(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.ruleSum(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if _, next, err = s.node43(cur); err != nil {
+		return nil, cur, err
+	}
+	cur = next
+	return results, cur, nil
+}
+
+// rule_ is _
+func (s *parserState) rule_(pos int) (interface{}, int, error) {
+	result, next, err := s.node3(pos)
+	action := s.actions[1]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "_")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node3 is ZeroOrMore in _
+func (s *parserState) node3(pos int) (interface{}, int, error) {
+	results := []interface{}{}
+	cur := pos
+	result, next, err := s.node4(cur)
+	for s.at(cur) != 0 && err == nil {
+		results = append(results, result)
+		cur = next
+		result, next, err = s.node4(cur)
+	}
+	return results, cur, nil
+}
+
+// node4 is ExpectAny(" \t\n") in _
+func (s *parserState) node4(pos int) (interface{}, int, error) {
+	switch s.at(pos) {
+	case 0:
+		return nil, pos, parserErr0
+	case ' ':
+		return " ", pos + 1, nil
+	case '\t':
+		return "\t", pos + 1, nil
+	case '\n':
+		return "\n", pos + 1, nil
+	}
+	return nil, pos, parserErr1
+}
+
+// ruleSum is Sum
+func (s *parserState) ruleSum(pos int) (interface{}, int, error) {
+	result, next, err := s.node6(pos)
+	action := s.actions[2]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Sum")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node6 is SeqOf in Sum
+func (s *parserState) node6(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.ruleProduct(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.node38(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// ruleProduct is Product
+func (s *parserState) ruleProduct(pos int) (interface{}, int, error) {
+	result, next, err := s.node8(pos)
+	action := s.actions[3]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Product")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node8 is SeqOf in Product
+func (s *parserState) node8(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.rulePower(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.node33(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// rulePower is Power
+func (s *parserState) rulePower(pos int) (interface{}, int, error) {
+	result, next, err := s.node10(pos)
+	action := s.actions[4]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Power")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node10 is SeqOf in Power
+func (s *parserState) node10(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.ruleValue(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.node30(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// ruleValue is Value
+func (s *parserState) ruleValue(pos int) (interface{}, int, error) {
+	result, next, err := s.node12(pos)
+	action := s.actions[5]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Value")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node12 is AnyOf in Value
+func (s *parserState) node12(pos int) (interface{}, int, error) {
+	result0, next0, err0 := s.ruleNumber(pos)
+	if err0 == nil {
+		return result0, next0, nil
+	}
+	result1, next1, err1 := s.ruleVariable(pos)
+	if err1 == nil {
+		return result1, next1, nil
+	}
+	result2, next2, err2 := s.node25(pos)
+	if err2 == nil {
+		return result2, next2, nil
+	}
+	result3, next3, err3 := s.node28(pos)
+	if err3 == nil {
+		return result3, next3, nil
+	}
+	return nil, pos, parserAllFailed{err0, err1, err2, err3}
+}
+
+// ruleNumber is Number
+func (s *parserState) ruleNumber(pos int) (interface{}, int, error) {
+	result, next, err := s.node14(pos)
+	action := s.actions[6]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Number")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node14 is SeqOf in Number
+func (s *parserState) node14(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.node15(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if _, next, err = s.node17(cur); err != nil {
+		return nil, cur, err
+	}
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// node15 is OneOrMore in Number
+func (s *parserState) node15(pos int) (interface{}, int, error) {
+	result, next, err := s.node16(pos)
+	if err != nil {
+		return nil, pos, err
+	}
+	results := []interface{}{}
+	cur := next
+	for err == nil {
+		results = append(results, result)
+		result, next, err = s.node16(cur)
+		cur = next
+	}
+	return results, cur, nil
+}
+
+// node16 is ExpectPredicate([0-9]) in Number
+func (s *parserState) node16(pos int) (interface{}, int, error) {
+	r := s.at(pos)
+	if r == 0 {
+		return nil, pos, parserErr2
+	}
+	if !('0' <= r && r <= '9') {
+		return nil, pos, parserErr3
+	}
+	return string(r), pos + 1, nil
+}
+
+// node17 is Not in Number
+func (s *parserState) node17(pos int) (interface{}, int, error) {
+	_, next, err := s.ruleLetter(pos)
+	if err == nil {
+		return nil, pos, s.errorAt(pos, parserUnexpected(s.text(pos, next)))
+	}
+	return nil, pos, nil
+}
+
+// ruleLetter is Letter
+func (s *parserState) ruleLetter(pos int) (interface{}, int, error) {
+	result, next, err := s.node19(pos)
+	action := s.actions[7]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Letter")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node19 is ExpectPredicate([a-zA-Z_]) in Letter
+func (s *parserState) node19(pos int) (interface{}, int, error) {
+	r := s.at(pos)
+	if r == 0 {
+		return nil, pos, parserErr4
+	}
+	if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '_') {
+		return nil, pos, parserErr5
+	}
+	return string(r), pos + 1, nil
+}
+
+// ruleVariable is Variable
+func (s *parserState) ruleVariable(pos int) (interface{}, int, error) {
+	result, next, err := s.node21(pos)
+	action := s.actions[8]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "Variable")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node21 is SeqOf in Variable
+func (s *parserState) node21(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 3)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.ruleLetter(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.node22(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// node22 is ZeroOrMore in Variable
+func (s *parserState) node22(pos int) (interface{}, int, error) {
+	results := []interface{}{}
+	cur := pos
+	result, next, err := s.node23(cur)
+	for s.at(cur) != 0 && err == nil {
+		results = append(results, result)
+		cur = next
+		result, next, err = s.node23(cur)
+	}
+	return results, cur, nil
+}
+
+// node23 is AnyOf in Variable
+func (s *parserState) node23(pos int) (interface{}, int, error) {
+	result0, next0, err0 := s.ruleLetter(pos)
+	if err0 == nil {
+		return result0, next0, nil
+	}
+	result1, next1, err1 := s.node24(pos)
+	if err1 == nil {
+		return result1, next1, nil
+	}
+	return nil, pos, parserAllFailed{err0, err1}
+}
+
+// node24 is ExpectPredicate([0-9]) in Variable
+func (s *parserState) node24(pos int) (interface{}, int, error) {
+	r := s.at(pos)
+	if r == 0 {
+		return nil, pos, parserErr2
+	}
+	if !('0' <= r && r <= '9') {
+		return nil, pos, parserErr3
+	}
+	return string(r), pos + 1, nil
+}
+
+// node25 is SeqOf in Value
+func (s *parserState) node25(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 5)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.node26(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.ruleSum(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.node27(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// node26 is ExpectString("(") in Value
+func (s *parserState) node26(pos int) (interface{}, int, error) {
+	if s.at(pos) == 0 {
+		return nil, pos, parserErr6
+	}
+	if s.at(pos+0) != '(' {
+		return nil, pos + 0, parserErr7
+	}
+	return "(", pos + 1, nil
+}
+
+// node27 is ExpectString(")") in Value
+func (s *parserState) node27(pos int) (interface{}, int, error) {
+	if s.at(pos) == 0 {
+		return nil, pos, parserErr8
+	}
+	if s.at(pos+0) != ')' {
+		return nil, pos + 0, parserErr9
+	}
+	return ")", pos + 1, nil
+}
+
+// node28 is SeqOf in Value
+func (s *parserState) node28(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 3)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.node29(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.ruleValue(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// node29 is ExpectString("-") in Value
+func (s *parserState) node29(pos int) (interface{}, int, error) {
+	if s.at(pos) == 0 {
+		return nil, pos, parserErr10
+	}
+	if s.at(pos+0) != '-' {
+		return nil, pos + 0, parserErr11
+	}
+	return "-", pos + 1, nil
+}
+
+// node30 is Optional in Power
+func (s *parserState) node30(pos int) (interface{}, int, error) {
+	result, next, err := s.node31(pos)
+	if err != nil {
+		return nil, pos, nil
+	}
+	return result, next, nil
+}
+
+// node31 is SeqOf in Power
+func (s *parserState) node31(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 3)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.node32(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rulePower(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// node32 is ExpectString("^") in Power
+func (s *parserState) node32(pos int) (interface{}, int, error) {
+	if s.at(pos) == 0 {
+		return nil, pos, parserErr12
+	}
+	if s.at(pos+0) != '^' {
+		return nil, pos + 0, parserErr13
+	}
+	return "^", pos + 1, nil
+}
+
+// node33 is ZeroOrMore in Product
+func (s *parserState) node33(pos int) (interface{}, int, error) {
+	results := []interface{}{}
+	cur := pos
+	result, next, err := s.node34(cur)
+	for s.at(cur) != 0 && err == nil {
+		results = append(results, result)
+		cur = next
+		result, next, err = s.node34(cur)
+	}
+	return results, cur, nil
+}
+
+// node34 is SeqOf in Product
+func (s *parserState) node34(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.ruleMulOp(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rulePower(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// ruleMulOp is MulOp
+func (s *parserState) ruleMulOp(pos int) (interface{}, int, error) {
+	result, next, err := s.node36(pos)
+	action := s.actions[9]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "MulOp")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node36 is SeqOf in MulOp
+func (s *parserState) node36(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.node37(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// node37 is ExpectAny("*/%") in MulOp
+func (s *parserState) node37(pos int) (interface{}, int, error) {
+	switch s.at(pos) {
+	case 0:
+		return nil, pos, parserErr14
+	case '*':
+		return "*", pos + 1, nil
+	case '/':
+		return "/", pos + 1, nil
+	case '%':
+		return "%", pos + 1, nil
+	}
+	return nil, pos, parserErr15
+}
+
+// node38 is ZeroOrMore in Sum
+func (s *parserState) node38(pos int) (interface{}, int, error) {
+	results := []interface{}{}
+	cur := pos
+	result, next, err := s.node39(cur)
+	for s.at(cur) != 0 && err == nil {
+		results = append(results, result)
+		cur = next
+		result, next, err = s.node39(cur)
+	}
+	return results, cur, nil
+}
+
+// node39 is SeqOf in Sum
+func (s *parserState) node39(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.ruleAddOp(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.ruleProduct(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// ruleAddOp is AddOp
+func (s *parserState) ruleAddOp(pos int) (interface{}, int, error) {
+	result, next, err := s.node41(pos)
+	action := s.actions[10]
+	if err != nil {
+		if action != nil {
+			next = pos
+		}
+		return nil, pos, s.withRule(next, err, "AddOp")
+	}
+	if action != nil {
+		result = action(result)
+	}
+	return result, next, nil
+}
+
+// node41 is SeqOf in AddOp
+func (s *parserState) node41(pos int) (interface{}, int, error) {
+	results := make([]interface{}, 0, 2)
+	cur := pos
+	var result interface{}
+	var next int
+	var err error
+	if result, next, err = s.node42(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	if result, next, err = s.rule_(cur); err != nil {
+		return nil, cur, err
+	}
+	results = append(results, result)
+	cur = next
+	return results, cur, nil
+}
+
+// node42 is ExpectAny("+-") in AddOp
+func (s *parserState) node42(pos int) (interface{}, int, error) {
+	switch s.at(pos) {
+	case 0:
+		return nil, pos, parserErr16
+	case '+':
+		return "+", pos + 1, nil
+	case '-':
+		return "-", pos + 1, nil
+	}
+	return nil, pos, parserErr17
+}
+
+// node43 is Not in Expr
+func (s *parserState) node43(pos int) (interface{}, int, error) {
+	_, next, err := s.node44(pos)
+	if err == nil {
+		return nil, pos, s.errorAt(pos, parserUnexpected(s.text(pos, next)))
+	}
+	return nil, pos, nil
+}
+
+// node44 is ExpectPredicate(any) in Expr
+func (s *parserState) node44(pos int) (interface{}, int, error) {
+	r := s.at(pos)
+	if r == 0 {
+		return nil, pos, parserErr18
+	}
+	return string(r), pos + 1, nil
+}
@@ -0,0 +1,41 @@
+// Code generated by parcomb-gen from arith.peg. DO NOT EDIT.
+
+package arith
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aziis98/parser-combinators/gen"
+	"github.com/aziis98/parser-combinators/peg"
+)
+
+const parserGrammar = `# Arithmetic expressions on integers with variables
+Expr     <- _ Sum !.
+Sum      <- Product (AddOp Product)*
+Product  <- Power (MulOp Power)*
+Power    <- Value ('^' _ Power)?
+Value    <- Number / Variable / '(' _ Sum ')' _ / '-' _ Value
+Number   <- [0-9]+ !Letter _
+Variable <- Letter (Letter / [0-9])* _
+Letter   <- [a-zA-Z_]
+AddOp    <- [+\-] _
+MulOp    <- [*/%] _
+_        <- [ \t\n]*
+`
+
+func TestParserAgreesWithGrammar(t *testing.T) {
+	g, err := peg.LoadGrammar(strings.NewReader(parserGrammar), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corpus, err := gen.ReadCorpus("testdata/corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gen.CheckAgreement(g.Rules[g.Start], (&Parser{}).Parse, corpus); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,102 @@
+package arith
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/aziis98/parser-combinators/gen"
+	"github.com/aziis98/parser-combinators/peg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]int{"x": 1, "y": 9}
+
+	{
+		r, err := Eval("2 * (x + 3) ^ 2 - -y / 4 % 5", vars)
+		assert.Nil(t, err)
+		assert.Equal(t, 34, r)
+	}
+	{
+		r, err := Eval(" 2 ^ 3 ^ 2 ", vars)
+		assert.Nil(t, err)
+		assert.Equal(t, 512, r)
+	}
+	{
+		_, err := Eval("1 / (x - 1)", vars)
+		assert.EqualError(t, err, `Division by zero`)
+	}
+	{
+		_, err := Eval("1 +\n)", vars)
+		assert.EqualError(t, err, `Unexpected "+" at 1:3 (in Expr)`)
+	}
+}
+
+func TestActionsAgree(t *testing.T) {
+	actions := (&evaluator{}).actions()
+
+	source, err := ioutil.ReadFile("arith.peg")
+	assert.Nil(t, err)
+	g, err := peg.LoadGrammar(bytes.NewReader(source), actions)
+	assert.Nil(t, err)
+
+	corpus, err := gen.ReadCorpus("testdata/corpus")
+	assert.Nil(t, err)
+	assert.Nil(t, gen.CheckAgreement(g.Rules[g.Start], (&Parser{Actions: actions}).Parse, corpus))
+}
+
+func TestGeneratedIsUpToDate(t *testing.T) {
+	source, err := ioutil.ReadFile("arith.peg")
+	assert.Nil(t, err)
+	g, err := peg.LoadGrammar(bytes.NewReader(source), nil)
+	assert.Nil(t, err)
+
+	names := []string{}
+	for name := range g.Rules {
+		if name != g.Start {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	rules := []c.Parser{}
+	for _, name := range names {
+		rules = append(rules, g.Rules[name])
+	}
+
+	code := &bytes.Buffer{}
+	assert.Nil(t, gen.Generate(code, g.Rules[g.Start], gen.Options{Package: "arith", Source: "arith.peg", Rules: rules}))
+
+	generated, err := ioutil.ReadFile("arith_gen.go")
+	assert.Nil(t, err)
+	assert.Equal(t, string(generated), code.String(), "run go generate")
+}
+
+var benchmarkInput = strings.Repeat("2 * (x + 3) ^ 2 - -y / 4 % 5 + ", 20) + "1"
+
+func BenchmarkInterpreted(b *testing.B) {
+	g, err := peg.LoadGrammar(strings.NewReader(parserGrammar), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		if _, err := g.Parse(strings.NewReader(benchmarkInput)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerated(b *testing.B) {
+	p := &Parser{}
+
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Parse(benchmarkInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,7 +1,5 @@
 package doc
 
-import "strings"
-
 // MinimarkNode ...
 type MinimarkNode interface {
 	Content() string
@@ -33,13 +31,16 @@ type List struct {
 	Items []*Item
 }
 
-// Content ...
+// Content returns the Markdown of the list, or "" when it can't be printed
 func (n *List) Content() string {
-	items := []string{}
-	for _, item := range n.Items {
-		items = append(items, item.Content())
-	}
-	return strings.Join(items, "\n")
+	text, _ := n.Markdown()
+	return text
+}
+
+// Markdown prints the list in its canonical form, it fails for items with a
+// negative depth or with more than one line of text
+func (n *List) Markdown() (string, error) {
+	return ListSyntax.Print(n)
 }
 
 // Item ...
@@ -48,7 +49,14 @@ type Item struct {
 	Text  string
 }
 
-// Content ...
+// Content returns the Markdown of the item, or "" when it can't be printed
 func (n *Item) Content() string {
-	return strings.Repeat("    ", n.Depth) + " - " + n.Text
+	text, _ := n.Markdown()
+	return text
+}
+
+// Markdown prints the item in its canonical form, it fails for a negative
+// depth or more than one line of text
+func (n *Item) Markdown() (string, error) {
+	return ItemSyntax.Print(n)
 }
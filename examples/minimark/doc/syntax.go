@@ -0,0 +1,112 @@
+package doc
+
+import (
+	"fmt"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/aziis98/parser-combinators/syntax"
+)
+
+// lineText is the rest of a line, possibly empty
+var lineText = syntax.Transform(
+	syntax.Optional(syntax.Chars(func(r rune) bool {
+		return r != '\n'
+	}, "not newline")),
+	syntax.Iso{
+		Apply: func(i interface{}) (interface{}, error) {
+			if i == nil {
+				return "", nil
+			}
+			return i, nil
+		},
+		Unapply: func(i interface{}) (interface{}, error) {
+			if i == "" {
+				return nil, nil
+			}
+			return i, nil
+		},
+	},
+)
+
+// HeadingSyntax parses a heading like "##\tText" and prints it in its
+// canonical form like "## Text", it is the Heading rule of the grammar
+var HeadingSyntax = syntax.Named("Heading",
+	syntax.Transform(
+		syntax.Seq(
+			syntax.Label(syntax.OneOrMore(syntax.Literal("#")), "heading marker"),
+			syntax.Skip(c.Label(c.InlineSpace, "space after #"), " "),
+			lineText,
+		),
+		syntax.Iso{
+			Apply: func(i interface{}) (interface{}, error) {
+				seq := i.([]interface{})
+				return &Heading{Level: len(seq[0].([]interface{})), Text: seq[1].(string)}, nil
+			},
+			Unapply: func(i interface{}) (interface{}, error) {
+				heading, ok := i.(*Heading)
+				if !ok || heading.Level < 1 {
+					return nil, fmt.Errorf(`Not a heading: %#v`, i)
+				}
+				return []interface{}{make([]interface{}, heading.Level), heading.Text}, nil
+			},
+		},
+	),
+)
+
+// ItemSyntax parses and prints the canonical form of an item, the Content of
+// Items, indented by four spaces for each level of depth
+var ItemSyntax = syntax.Named("Item",
+	syntax.Transform(
+		syntax.Seq(
+			syntax.ZeroOrMore(syntax.Literal("    ")),
+			syntax.Literal(" - "),
+			lineText,
+		),
+		syntax.Iso{
+			Apply: func(i interface{}) (interface{}, error) {
+				seq := i.([]interface{})
+				return &Item{Depth: len(seq[0].([]interface{})), Text: seq[1].(string)}, nil
+			},
+			Unapply: func(i interface{}) (interface{}, error) {
+				item, ok := i.(*Item)
+				if !ok || item.Depth < 0 {
+					return nil, fmt.Errorf(`Not an item: %#v`, i)
+				}
+				if strings.Contains(item.Text, "\n") {
+					return nil, fmt.Errorf(`Item text %q has more than one line`, item.Text)
+				}
+				return []interface{}{make([]interface{}, item.Depth), item.Text}, nil
+			},
+		},
+	),
+)
+
+// ListSyntax parses and prints the canonical form of lists, the Content of
+// Lists. The grammar also accepts lists indented in other ways.
+var ListSyntax = syntax.Named("List",
+	syntax.Transform(
+		syntax.SepBy(ItemSyntax, syntax.Literal("\n")),
+		syntax.Iso{
+			Apply: func(i interface{}) (interface{}, error) {
+				list := &List{Items: []*Item{}}
+				for _, item := range i.([]interface{}) {
+					list.Items = append(list.Items, item.(*Item))
+				}
+				return list, nil
+			},
+			Unapply: func(i interface{}) (interface{}, error) {
+				list, ok := i.(*List)
+				if !ok {
+					return nil, fmt.Errorf(`Not a list: %#v`, i)
+				}
+
+				items := []interface{}{}
+				for _, item := range list.Items {
+					items = append(items, item)
+				}
+				return items, nil
+			},
+		},
+	),
+)
@@ -0,0 +1,48 @@
+// Command gendoc generates the grammar documentation of Minimark from the
+// parsers in the "parser" package, run it with "go generate" from the
+// minimark directory.
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+
+	"github.com/aziis98/parser-combinators/examples/minimark/parser"
+	"github.com/aziis98/parser-combinators/grammar"
+)
+
+func main() {
+	doc, svg, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile("GRAMMAR.md", doc, 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile("grammar.svg", svg, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the contents of GRAMMAR.md and grammar.svg
+func generate() ([]byte, []byte, error) {
+	doc := &bytes.Buffer{}
+	doc.WriteString("# Minimark Grammar\n\n")
+	doc.WriteString("<!-- Code generated by gendoc from parser/parser.go. DO NOT EDIT. -->\n\n")
+	doc.WriteString("```ebnf\n")
+	if err := grammar.WriteEBNF(doc, parser.Minimark); err != nil {
+		return nil, nil, err
+	}
+	doc.WriteString("```\n\n")
+	doc.WriteString("![Railroad diagrams](grammar.svg)\n")
+
+	svg := &bytes.Buffer{}
+	if err := grammar.WriteRailroad(svg, parser.Minimark); err != nil {
+		return nil, nil, err
+	}
+
+	return doc.Bytes(), svg.Bytes(), nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedUpToDate(t *testing.T) {
+	doc, svg, err := generate()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for file, generated := range map[string][]byte{"../GRAMMAR.md": doc, "../grammar.svg": svg} {
+		committed, err := ioutil.ReadFile(file)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.Equal(t, string(generated), string(committed), "%s is stale, run \"go generate\" in examples/minimark", file)
+	}
+}
@@ -0,0 +1,32 @@
+// Command minimark-lsp is a language server for Minimark documents, editors
+// start it and talk to it through its standard input and output.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/aziis98/parser-combinators/examples/minimark/parser"
+	"github.com/aziis98/parser-combinators/lsp"
+)
+
+func main() {
+	server := lsp.NewServer(parser.Minimark, lsp.Hooks{
+		Name: "minimark",
+		Symbols: map[string]lsp.SymbolKind{
+			"Heading": lsp.SymbolKindNamespace,
+			"List":    lsp.SymbolKindArray,
+		},
+		TokenTypes: map[string]string{
+			"heading marker": "keyword",
+			"bullet":         "operator",
+			"Heading":        "class",
+			"Item":           "string",
+		},
+	})
+
+	// the standard output is the connection, logs go to the standard error
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
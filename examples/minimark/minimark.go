@@ -1,3 +1,5 @@
+//go:generate go run ./gendoc
+
 package minimark
 
 import "github.com/aziis98/parser-combinators/examples/minimark/parser"
@@ -1,12 +1,16 @@
 package minimark
 
 import (
+	"fmt"
+	"math/rand"
 	"strings"
 	"testing"
 
 	c "github.com/aziis98/parser-combinators"
 	"github.com/aziis98/parser-combinators/examples/minimark/doc"
 	"github.com/aziis98/parser-combinators/examples/minimark/parser"
+	"github.com/aziis98/parser-combinators/parcombtest"
+	"github.com/aziis98/parser-combinators/syntax"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -60,6 +64,86 @@ func TestMinimark(t *testing.T) {
 	}
 }
 
+func TestGolden(t *testing.T) {
+	parcombtest.Golden(t, parser.Minimark, "testdata/golden/minimark")
+	parcombtest.Golden(t, parser.Heading, "testdata/golden/heading")
+}
+
+func TestMinimarkErrors(t *testing.T) {
+	_, err := c.ParseRuneReader(parser.Heading, strings.NewReader("##Prova"))
+	assert.EqualError(t, err, `Expected space after # at 1:3 (in Heading)`)
+}
+
+func TestNestedList(t *testing.T) {
+	list := &doc.List{
+		Items: []*doc.Item{
+			{Depth: 0, Text: "First"},
+			{Depth: 1, Text: "Nested"},
+			{Depth: 2, Text: "Deeper"},
+			{Depth: 1, Text: "Tab indented"},
+			{Depth: 0, Text: "Second"},
+		},
+	}
+
+	{
+		r, _ := c.ParseRuneReader(parser.List, strings.NewReader(" - First\n     - Nested\n         - Deeper\n\t - Tab indented\n - Second\n"))
+		assert.Equal(t, list, r)
+	}
+	{
+		r, _ := c.ParseRuneReader(parser.List, strings.NewReader(list.Content()+"\n"))
+		assert.Equal(t, list, r)
+	}
+}
+
+func TestSyntax(t *testing.T) {
+	list := &doc.List{
+		Items: []*doc.Item{
+			{Depth: 0, Text: "First"},
+			{Depth: 1, Text: "Nested"},
+			{Depth: 2, Text: ""},
+			{Depth: 0, Text: "Second"},
+		},
+	}
+
+	{
+		text, err := doc.ListSyntax.Print(list)
+		assert.Nil(t, err)
+		assert.Equal(t, " - First\n     - Nested\n         - \n - Second", text)
+		assert.Equal(t, text, list.Content())
+		assert.Nil(t, syntax.RoundTrip(doc.ListSyntax, list))
+
+		// the printed text is also a valid list for the main grammar
+		r, err := c.ParseRuneReader(parser.List, strings.NewReader(text+"\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, list, r)
+	}
+	{
+		_, err := (&doc.Item{Depth: -1, Text: "Item"}).Markdown()
+		assert.EqualError(t, err, `Item: Not an item: &doc.Item{Depth:-1, Text:"Item"}`)
+
+		multiline := &doc.List{Items: []*doc.Item{{Text: "One\nTwo"}}}
+		_, err = multiline.Markdown()
+		assert.EqualError(t, err, `List: Item: Item text "One\nTwo" has more than one line`)
+		assert.Equal(t, "", multiline.Content())
+	}
+	{
+		heading := &doc.Heading{Level: 2, Text: "Prova"}
+
+		text, err := doc.HeadingSyntax.Print(heading)
+		assert.Nil(t, err)
+		assert.Equal(t, "## Prova", text)
+		assert.Nil(t, syntax.RoundTrip(doc.HeadingSyntax, heading))
+
+		// the grammar uses the syntax of headings, that also accepts tabs
+		r, err := c.ParseRuneReader(parser.Heading, strings.NewReader("##\tProva"))
+		assert.Nil(t, err)
+		assert.Equal(t, heading, r)
+
+		_, err = doc.HeadingSyntax.Print(&doc.Heading{Level: 0})
+		assert.EqualError(t, err, `Heading: Not a heading: &doc.Heading{Level:0, Text:""}`)
+	}
+}
+
 func Benchmark1(b *testing.B) {
 	var r interface{}
 
@@ -69,3 +153,86 @@ func Benchmark1(b *testing.B) {
 
 	b.Log(r)
 }
+
+func BenchmarkWithoutDispatch(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		c.ParseRuneReader(parser.Minimark, strings.NewReader(example1), c.WithoutDispatch())
+	}
+}
+
+func TestGrammar(t *testing.T) {
+	assert.Nil(t, c.Analyze(parser.Minimark).Err())
+}
+
+func TestCompiled(t *testing.T) {
+	program := c.Compile(parser.Minimark)
+
+	for _, input := range []string{example1, "##Prova", " - First\n     - Nested\n - Second\n", "text\n\n# Heading\n"} {
+		expected, expectedErr := c.ParseRuneReader(parser.Minimark, strings.NewReader(input))
+		actual, actualErr := c.ParseRuneReader(program, strings.NewReader(input))
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, fmt.Sprint(expectedErr), fmt.Sprint(actualErr))
+	}
+}
+
+func TestCST(t *testing.T) {
+	r, root, err := c.ParseCST(parser.Minimark, strings.NewReader(example1))
+	assert.Nil(t, err)
+	assert.Equal(t, example1, root.Source())
+
+	expected, _ := c.ParseRuneReader(parser.Minimark, strings.NewReader(example1))
+	assert.Equal(t, expected, r)
+}
+
+func TestIncremental(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	fragments := []string{"", "\n", "\n\n", "# ", "## ", " - ", "   - ", "word", " "}
+
+	p := c.NewIncrementalParser(parser.Minimark)
+	p.Parse(example1)
+
+	reused := 0
+	for i := 0; i < 500; i++ {
+		text := []rune(p.Text())
+		start := random.Intn(len(text) + 1)
+		end := start + random.Intn(len(text)-start+1)%4
+
+		actual, actualErr := p.Edit(start, end, fragments[random.Intn(len(fragments))])
+		expected, expectedErr := c.ParseRuneReader(parser.Minimark, strings.NewReader(p.Text()))
+		reused += p.Reused()
+
+		assert.Equal(t, expected, actual, "text %q", p.Text())
+		assert.Equal(t, fmt.Sprint(expectedErr), fmt.Sprint(actualErr), "text %q", p.Text())
+	}
+
+	assert.NotZero(t, reused)
+}
+
+var longDocument = strings.Repeat(example1, 20)
+
+func BenchmarkReparse(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		c.ParseRuneReader(parser.Minimark, strings.NewReader(longDocument))
+	}
+}
+
+func BenchmarkIncremental(b *testing.B) {
+	p := c.NewIncrementalParser(parser.Minimark)
+	p.Parse(longDocument)
+
+	for n := 0; n < b.N; n++ {
+		// types a word in the first paragraph
+		p.Edit(100, 100, "word")
+		b.StopTimer()
+		p.Edit(100, 104, "")
+		b.StartTimer()
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	program := c.Compile(parser.Minimark)
+
+	for n := 0; n < b.N; n++ {
+		c.ParseRuneReader(program, strings.NewReader(example1))
+	}
+}
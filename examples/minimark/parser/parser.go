@@ -6,110 +6,126 @@ import (
 )
 
 // Heading ...
-var Heading = c.Transform(
-	c.SeqOf(
-		c.Transform(
-			c.OneOrMore(c.Expect('#')),
-			func(i interface{}) interface{} {
-				return len(i.([]interface{}))
-			},
-		),
-		c.SeqIgnore(c.InlineSpace),
-		c.StringifyResult(
-			c.ZeroOrMore(
-				c.ExpectPredicate(func(r rune) bool {
-					return r != '\n'
-				}),
-			),
-		),
-	),
-	func(iSeq interface{}) interface{} {
-		seq := iSeq.([]interface{})
-		level := seq[0].(int)
-		text := seq[1].(string)
-		return &doc.Heading{Level: level, Text: text}
-	},
-)
+var Heading c.Parser = doc.HeadingSyntax
 
 // Paragraph ...
-var Paragraph = c.Transform(
-	c.StringifyResult(
-		c.RepeatUntil(
-			c.Any,
-			c.AnyOf(
-				c.SeqOf(
-					c.Expect('\n'),
+var Paragraph = c.Named("Paragraph",
+	c.Transform(
+		c.StringifyResult(
+			c.SeqOf(
+				// the first rune keeps paragraphs from matching the empty end of the document
+				c.Any,
+				c.RepeatUntil(
+					c.Any,
 					c.AnyOf(
-						c.Expect('\n'),
+						c.SeqOf(
+							c.Expect('\n'),
+							c.AnyOf(
+								c.Expect('\n'),
+								c.EOF,
+							),
+						),
 						c.EOF,
 					),
 				),
-				c.EOF,
 			),
 		),
+		func(i interface{}) interface{} {
+			text := i.(string)
+			return &doc.Paragraph{Text: text}
+		},
 	),
-	func(i interface{}) interface{} {
-		text := i.(string)
-		return &doc.Paragraph{Text: text}
-	},
 )
 
 // List ...
-var List = c.Transform(
-	c.OneOrMore(
-		Item,
+var List = c.Named("List",
+	c.Transform(
+		listLevelRef,
+		func(i interface{}) interface{} {
+			return &doc.List{Items: flattenItems(i.([]interface{}), 0)}
+		},
 	),
-	func(i interface{}) interface{} {
-		items := []*doc.Item{}
-		for _, iItem := range i.([]interface{}) {
-			items = append(items, &doc.Item{Depth: 0, Text: iItem.(string)})
-		}
-		return &doc.List{Items: items}
-	},
 )
 
-// Item ...
-var Item = c.Transform(
-	c.SeqOf(
-		c.SeqIgnore(
-			c.ExpectString([]rune(" - ")),
-		),
-		c.StringifyResult(
-			c.ZeroOrMore(
-				c.ExpectPredicate(func(r rune) bool {
-					return r != '\n'
-				}),
+// listLevel parses the items at the same indentation, each followed by its
+// more indented sub-items
+var listLevel c.Parser
+
+// listLevelRef breaks the initialization cycle of the recursive "listLevel"
+var listLevelRef = c.Lazy(func() c.Parser {
+	return listLevel
+})
+
+func init() {
+	listLevel = c.Named("ListLevel",
+		c.Block(
+			c.SeqOf(
+				Item,
+				c.Optional(listLevelRef),
 			),
 		),
-		c.SeqIgnore(
-			c.Newline,
+	)
+}
+
+func flattenItems(level []interface{}, depth int) []*doc.Item {
+	items := []*doc.Item{}
+	for _, iEntry := range level {
+		entry := iEntry.([]interface{})
+		items = append(items, &doc.Item{Depth: depth, Text: entry[0].(string)})
+
+		if entry[1] != nil {
+			items = append(items, flattenItems(entry[1].([]interface{}), depth+1)...)
+		}
+	}
+	return items
+}
+
+// Item ...
+var Item = c.Named("Item",
+	c.Transform(
+		c.SeqOf(
+			c.SeqIgnore(
+				c.Label(c.ExpectString([]rune("- ")), "bullet"),
+			),
+			c.StringifyResult(
+				c.ZeroOrMore(
+					c.ExpectPredicate(func(r rune) bool {
+						return r != '\n'
+					}, "not newline"),
+				),
+			),
+			c.SeqIgnore(
+				c.Newline,
+			),
 		),
+		func(i interface{}) interface{} {
+			return i.([]interface{})[0]
+		},
 	),
-	func(i interface{}) interface{} {
-		return i.([]interface{})[0]
-	},
 )
 
 // Minimark ...
-var Minimark = c.Transform(
-	c.ZeroOrMore(
-		c.AnyOf(
-			c.Newline,
-			Heading,
-			List,
-			Paragraph,
+var Minimark = c.Named("Minimark",
+	c.Transform(
+		c.ZeroOrMore(
+			c.AnyOf(
+				c.Newline,
+				Heading,
+				List,
+				Paragraph,
+			),
 		),
-	),
-	func(i interface{}) interface{} {
-		nodes := i.([]interface{})
-		result := []doc.MinimarkNode{}
+		func(i interface{}) interface{} {
+			nodes := i.([]interface{})
+			result := []doc.MinimarkNode{}
 
-		for _, node := range nodes {
-			if _, ok := node.(string); !ok {
-				result = append(result, node.(doc.MinimarkNode))
+			for _, node := range nodes {
+				if _, ok := node.(string); !ok {
+					result = append(result, node.(doc.MinimarkNode))
+				}
 			}
-		}
 
-		return result
-	},
+			return result
+		},
+	),
 )
@@ -0,0 +1,66 @@
+package gen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// CheckAgreement parses each input of a corpus with the interpreted grammar
+// and with the parse function of its generated parser, and returns an error
+// describing the first input where their results or error messages differ
+func CheckAgreement(interpreted c.Parser, generated func(input string) (interface{}, error), corpus map[string]string) error {
+	names := []string{}
+	for name := range corpus {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		input := corpus[name]
+
+		expected, expectedErr := c.ParseRuneReader(interpreted, strings.NewReader(input))
+		actual, actualErr := generated(input)
+
+		if (expectedErr == nil) != (actualErr == nil) || expectedErr != nil && expectedErr.Error() != actualErr.Error() {
+			return fmt.Errorf("Input %s: the grammar returned the error %v but the generated parser %v", name, expectedErr, actualErr)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Errorf("Input %s: the grammar returned %#v but the generated parser %#v", name, expected, actual)
+		}
+	}
+
+	return nil
+}
+
+// ReadCorpus reads the files in a directory as a corpus for CheckAgreement,
+// keyed by their path
+func ReadCorpus(dir string) (map[string]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	corpus := map[string]string{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		corpus[path] = string(data)
+	}
+
+	return corpus, nil
+}
@@ -0,0 +1,460 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// prefix is the prefix of the unexported identifiers of the generated code,
+// so that parsers of different grammars can live in the same package
+func (g *generator) prefix() string {
+	return strings.ToLower(g.opts.Type[:1]) + g.opts.Type[1:]
+}
+
+func (g *generator) errorType() string {
+	return strings.TrimSuffix(g.opts.Type, "Parser") + "ParseError"
+}
+
+// errorVar returns the variable of a constant error message
+func (g *generator) errorVar(message string) string {
+	if name, ok := g.errors[message]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("%sErr%d", g.prefix(), len(g.errorOrder))
+	g.errors[message] = name
+	g.errorOrder = append(g.errorOrder, message)
+	return name
+}
+
+func (g *generator) function(n *node) string {
+	if n.desc.Kind == c.KindNamed {
+		return n.rule.ident
+	}
+
+	return fmt.Sprintf("node%d", n.id)
+}
+
+func (g *generator) emit(start *node) []byte {
+	body := &bytes.Buffer{}
+	for _, n := range g.order {
+		g.emitNode(body, n)
+	}
+
+	out := &bytes.Buffer{}
+	source := ""
+	if g.opts.Source != "" {
+		source = " from " + g.opts.Source
+	}
+	fmt.Fprintf(out, "// Code generated by parcomb-gen%s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(out, "package %s\n\n", g.opts.Package)
+
+	if len(g.errorOrder) > 0 {
+		g.imports["errors"] = true
+	}
+	imports := []string{}
+	for name := range g.imports {
+		imports = append(imports, strconv.Quote(name))
+	}
+	sort.Strings(imports)
+	fmt.Fprintf(out, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+
+	actions := []string{}
+	ruleNames := []string{}
+	cases := &bytes.Buffer{}
+	for _, r := range g.rules {
+		if r.action {
+			actions = append(actions, strconv.Quote(r.name))
+		}
+		ruleNames = append(ruleNames, strconv.Quote(r.name))
+		fmt.Fprintf(cases, "case %s:\nparse = s.%s\n", strconv.Quote(r.name), r.ident)
+	}
+
+	actionsDoc := ""
+	if len(actions) > 0 {
+		actionsDoc = fmt.Sprintf("\n// The grammar has actions for %s: set them to get the same results.", strings.Join(actions, ", "))
+	}
+
+	replacer := strings.NewReplacer(
+		"TYPE", g.opts.Type,
+		"ERROR", g.errorType(),
+		"PREFIX", g.prefix(),
+		"START", g.function(start),
+		"RULECOUNT", strconv.Itoa(len(g.rules)),
+		"RULENAMES", strings.Join(ruleNames, ", "),
+		"RULECASES", cases.String(),
+		"ACTIONSDOC", actionsDoc,
+	)
+	replacer.WriteString(out, runtime)
+
+	for _, message := range g.errorOrder {
+		fmt.Fprintf(out, "var %s = errors.New(%s)\n", g.errors[message], strconv.Quote(message))
+	}
+	for _, predicate := range g.predicates {
+		fmt.Fprintln(out, predicate)
+	}
+	out.WriteString("\n")
+
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// runtime is the fixed part of the generated code, the upper case
+// placeholders are replaced by emit
+const runtime = `// TYPE is a generated recursive descent parser, its results and errors are
+// the same as the ones of the grammar it was generated from.
+type TYPE struct {
+	// Actions are semantic actions by rule name, each one transforms the
+	// result of its rule.ACTIONSDOC
+	Actions map[string]func(interface{}) interface{}
+}
+
+// Parse parses the input with the start rule
+func (p *TYPE) Parse(input string) (interface{}, error) {
+	result, _, err := p.newState(input).START(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ParseRule parses the input with the given rule
+func (p *TYPE) ParseRule(rule, input string) (interface{}, error) {
+	s := p.newState(input)
+
+	var parse func(int) (interface{}, int, error)
+	switch rule {
+	RULECASES
+	default:
+		return nil, fmt.Errorf(` + "`" + `Unknown rule "%s"` + "`" + `, rule)
+	}
+
+	result, _, err := parse(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+var PREFIXRuleNames = [RULECOUNT]string{RULENAMES}
+
+// PREFIXState is the state of a single parse, its methods parse a part of the
+// grammar at the given offset and return its result, the offset after it and
+// an error. On failure the offset is the one the grammar would report.
+type PREFIXState struct {
+	input   []rune
+	actions [RULECOUNT]func(interface{}) interface{}
+}
+
+func (p *TYPE) newState(input string) *PREFIXState {
+	s := &PREFIXState{input: []rune(input)}
+	for i, name := range PREFIXRuleNames {
+		s.actions[i] = p.Actions[name]
+	}
+
+	return s
+}
+
+// at returns the rune at the given offset, or 0 at the end of the input
+func (s *PREFIXState) at(pos int) rune {
+	if pos < len(s.input) {
+		return s.input[pos]
+	}
+
+	return 0
+}
+
+// text returns the input between two offsets
+func (s *PREFIXState) text(from, to int) string {
+	if to > len(s.input) {
+		to = len(s.input)
+	}
+	if from > to {
+		return ""
+	}
+
+	return string(s.input[from:to])
+}
+
+func (s *PREFIXState) errorAt(pos int, err error) error {
+	return &ERROR{Offset: pos, Err: err, input: s.input}
+}
+
+// withRule returns a copy of the error with the given rule on top of its rule stack
+func (s *PREFIXState) withRule(pos int, err error, rule string) error {
+	pe, ok := err.(*ERROR)
+	if !ok {
+		pe = &ERROR{Offset: pos, Err: err, input: s.input}
+	}
+
+	located := *pe
+	located.Rules = append([]string{rule}, pe.Rules...)
+	return &located
+}
+
+// ERROR is a syntax error with its position
+type ERROR struct {
+	Offset int
+	Err    error
+	// Rules is the stack of rules the error happened in, outermost first
+	Rules []string
+
+	input []rune
+}
+
+// Location returns the one based line and column of the error
+func (e *ERROR) Location() (int, int) {
+	end := e.Offset
+	if end > len(e.input) {
+		end = len(e.input)
+	}
+
+	line, start := 1, 0
+	for i, r := range e.input[:end] {
+		if r == '\n' {
+			line, start = line+1, i+1
+		}
+	}
+
+	return line, end - start + 1
+}
+
+func (e *ERROR) Error() string {
+	line, col := e.Location()
+	msg := fmt.Sprintf("%v at %d:%d", e.Err, line, col)
+
+	if len(e.Rules) > 0 {
+		msg += fmt.Sprintf(" (in %s)", strings.Join(e.Rules, " > "))
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *ERROR) Unwrap() error {
+	return e.Err
+}
+
+// PREFIXAllFailed are the errors of the alternatives of a choice
+type PREFIXAllFailed []error
+
+func (errs PREFIXAllFailed) Error() string {
+	lines := []string{}
+	for _, err := range errs {
+		lines = append(lines, fmt.Sprintf(" - %v", err))
+	}
+
+	return fmt.Sprintf("All cases failed:\n%s", strings.Join(lines, "\n"))
+}
+
+// PREFIXUnexpected is the error of a negative lookahead that matched some text
+type PREFIXUnexpected string
+
+func (text PREFIXUnexpected) Error() string {
+	return fmt.Sprintf(` + "`" + `Unexpected "%s"` + "`" + `, string(text))
+}
+
+func PREFIXStringify(i interface{}) string {
+	switch v := i.(type) {
+	case []interface{}:
+		str := ""
+		for _, vv := range v {
+			str += PREFIXStringify(vv)
+		}
+		return str
+	case string:
+		return v
+	}
+
+	return fmt.Sprint(i)
+}
+
+`
+
+// emitNode writes the function of a node, mirroring the combinator it was
+// generated from
+func (g *generator) emitNode(w *bytes.Buffer, n *node) {
+	child := func(i int) string {
+		return "s." + g.function(n.children[i])
+	}
+
+	fmt.Fprintf(w, "// %s is %s", g.function(n), n.desc.String())
+	if n.desc.Kind != c.KindNamed && n.rule != nil {
+		fmt.Fprintf(w, " in %s", n.rule.name)
+	}
+	fmt.Fprintf(w, "\nfunc (s *%sState) %s(pos int) (interface{}, int, error) {\n", g.prefix(), g.function(n))
+	defer w.WriteString("}\n\n")
+
+	switch n.desc.Kind {
+	case c.KindExpect:
+		r := []rune(n.desc.Text)[0]
+		fmt.Fprintf(w, "r := s.at(pos)\n")
+		fmt.Fprintf(w, "if r == 0 {\nreturn nil, pos, %s\n}\n", g.errorVar(fmt.Sprintf(`Stream ended, expected "%c"`, r)))
+		fmt.Fprintf(w, "if r != %s {\nreturn nil, pos, %s\n}\n", strconv.QuoteRune(r), g.errorVar(fmt.Sprintf(`Expected "%c"`, r)))
+		fmt.Fprintf(w, "return %s, pos + 1, nil\n", strconv.Quote(string(r)))
+
+	case c.KindExpectPredicate:
+		descriptions := []string{}
+		if n.desc.Text != "" {
+			descriptions = strings.Split(n.desc.Text, ", ")
+		}
+		fmt.Fprintf(w, "r := s.at(pos)\n")
+		fmt.Fprintf(w, "if r == 0 {\nreturn nil, pos, %s\n}\n", g.errorVar(fmt.Sprintf(`Stream ended, expected "%v"`, descriptions)))
+		if n.predicate != "true" {
+			fmt.Fprintf(w, "if !(%s) {\nreturn nil, pos, %s\n}\n", n.predicate, g.errorVar(fmt.Sprintf(`Expected "%+v"`, descriptions)))
+		}
+		fmt.Fprintf(w, "return string(r), pos + 1, nil\n")
+
+	case c.KindExpectAny:
+		list := strings.Join(strings.Split(n.desc.Text, ""), ", ")
+		if n.desc.Text == "" {
+			fmt.Fprintf(w, "return nil, pos, %s\n", g.errorVar(fmt.Sprintf(`Expected one of %v`, list)))
+			return
+		}
+
+		fmt.Fprintf(w, "switch s.at(pos) {\n")
+		fmt.Fprintf(w, "case 0:\nreturn nil, pos, %s\n", g.errorVar(fmt.Sprintf(`Stream ended, expected one of %v`, list)))
+		seen := map[rune]bool{0: true}
+		for _, r := range n.desc.Text {
+			if !seen[r] {
+				seen[r] = true
+				fmt.Fprintf(w, "case %s:\nreturn %s, pos + 1, nil\n", strconv.QuoteRune(r), strconv.Quote(string(r)))
+			}
+		}
+		fmt.Fprintf(w, "}\n")
+		fmt.Fprintf(w, "return nil, pos, %s\n", g.errorVar(fmt.Sprintf(`Expected one of %v`, list)))
+
+	case c.KindExpectString:
+		if n.desc.Text == "" {
+			fmt.Fprintf(w, "return \"\", pos, nil\n")
+			return
+		}
+
+		fmt.Fprintf(w, "if s.at(pos) == 0 {\nreturn nil, pos, %s\n}\n", g.errorVar(fmt.Sprintf(`Stream ended, expected "%s"`, n.desc.Text)))
+		for i, r := range []rune(n.desc.Text) {
+			fmt.Fprintf(w, "if s.at(pos+%d) != %s {\nreturn nil, pos + %d, %s\n}\n", i, strconv.QuoteRune(r), i, g.errorVar(fmt.Sprintf(`Expected "%c"`, r)))
+		}
+		fmt.Fprintf(w, "return %s, pos + %d, nil\n", strconv.Quote(n.desc.Text), len([]rune(n.desc.Text)))
+
+	case c.KindEOF:
+		fmt.Fprintf(w, "if s.at(pos) == 0 {\nreturn \"\\x00\", pos + 1, nil\n}\n")
+		fmt.Fprintf(w, "return nil, pos, %s\n", g.errorVar(`Expected end of stream`))
+
+	case c.KindSeqOf:
+		collected := 0
+		for _, ignored := range n.ignored {
+			if !ignored {
+				collected++
+			}
+		}
+		if len(n.children) == 0 {
+			fmt.Fprintf(w, "return []interface{}{}, pos, nil\n")
+			return
+		}
+
+		fmt.Fprintf(w, "results := make([]interface{}, 0, %d)\n", collected)
+		fmt.Fprintf(w, "cur := pos\n")
+		if collected > 0 {
+			fmt.Fprintf(w, "var result interface{}\n")
+		}
+		fmt.Fprintf(w, "var next int\nvar err error\n")
+		for i, ignored := range n.ignored {
+			target := "result"
+			if ignored {
+				target = "_"
+			}
+			fmt.Fprintf(w, "if %s, next, err = %s(cur); err != nil {\nreturn nil, cur, err\n}\n", target, child(i))
+			if !ignored {
+				fmt.Fprintf(w, "results = append(results, result)\n")
+			}
+			fmt.Fprintf(w, "cur = next\n")
+		}
+		fmt.Fprintf(w, "return results, cur, nil\n")
+
+	case c.KindAnyOf:
+		errs := []string{}
+		for i := range n.children {
+			fmt.Fprintf(w, "result%d, next%d, err%d := %s(pos)\n", i, i, i, child(i))
+			fmt.Fprintf(w, "if err%d == nil {\nreturn result%d, next%d, nil\n}\n", i, i, i)
+			errs = append(errs, fmt.Sprintf("err%d", i))
+		}
+		fmt.Fprintf(w, "return nil, pos, %sAllFailed{%s}\n", g.prefix(), strings.Join(errs, ", "))
+
+	case c.KindRepeatUntil:
+		fmt.Fprintf(w, "results := []interface{}{}\ncur := pos\n")
+		fmt.Fprintf(w, "_, _, err := %s(cur)\n", child(1))
+		fmt.Fprintf(w, "for err != nil {\n")
+		fmt.Fprintf(w, "result, next, err2 := %s(cur)\n", child(0))
+		fmt.Fprintf(w, "if err2 != nil {\nreturn nil, pos, err2\n}\n")
+		fmt.Fprintf(w, "results = append(results, result)\ncur = next\n")
+		fmt.Fprintf(w, "_, _, err = %s(cur)\n", child(1))
+		fmt.Fprintf(w, "}\n")
+		fmt.Fprintf(w, "return results, cur, nil\n")
+
+	case c.KindOneOrMore:
+		// a failed repetition still moves to the offset of its error, as the combinator does
+		fmt.Fprintf(w, "result, next, err := %s(pos)\n", child(0))
+		fmt.Fprintf(w, "if err != nil {\nreturn nil, pos, err\n}\n")
+		fmt.Fprintf(w, "results := []interface{}{}\ncur := next\n")
+		fmt.Fprintf(w, "for err == nil {\n")
+		fmt.Fprintf(w, "results = append(results, result)\n")
+		fmt.Fprintf(w, "result, next, err = %s(cur)\ncur = next\n", child(0))
+		fmt.Fprintf(w, "}\n")
+		fmt.Fprintf(w, "return results, cur, nil\n")
+
+	case c.KindZeroOrMore:
+		fmt.Fprintf(w, "results := []interface{}{}\ncur := pos\n")
+		fmt.Fprintf(w, "result, next, err := %s(cur)\n", child(0))
+		fmt.Fprintf(w, "for s.at(cur) != 0 && err == nil {\n")
+		fmt.Fprintf(w, "results = append(results, result)\ncur = next\n")
+		fmt.Fprintf(w, "result, next, err = %s(cur)\n", child(0))
+		fmt.Fprintf(w, "}\n")
+		fmt.Fprintf(w, "return results, cur, nil\n")
+
+	case c.KindOptional:
+		fmt.Fprintf(w, "result, next, err := %s(pos)\n", child(0))
+		fmt.Fprintf(w, "if err != nil {\nreturn nil, pos, nil\n}\n")
+		fmt.Fprintf(w, "return result, next, nil\n")
+
+	case c.KindNot:
+		fmt.Fprintf(w, "_, next, err := %s(pos)\n", child(0))
+		fmt.Fprintf(w, "if err == nil {\nreturn nil, pos, s.errorAt(pos, %sUnexpected(s.text(pos, next)))\n}\n", g.prefix())
+		fmt.Fprintf(w, "return nil, pos, nil\n")
+
+	case c.KindLookahead:
+		fmt.Fprintf(w, "result, _, err := %s(pos)\n", child(0))
+		fmt.Fprintf(w, "if err != nil {\nreturn nil, pos, err\n}\n")
+		fmt.Fprintf(w, "return result, pos, nil\n")
+
+	case c.KindTransform:
+		fmt.Fprintf(w, "result, next, err := %s(pos)\n", child(0))
+		fmt.Fprintf(w, "if err != nil {\nreturn nil, pos, err\n}\n")
+		if n.desc.Text == "StringifyResult" {
+			fmt.Fprintf(w, "return %sStringify(result), next, nil\n", g.prefix())
+		} else {
+			fmt.Fprintf(w, "return result.([]interface{})[0], next, nil\n")
+		}
+
+	case c.KindLabel:
+		fmt.Fprintf(w, "result, next, err := %s(pos)\n", child(0))
+		fmt.Fprintf(w, "if err == nil {\nreturn result, next, nil\n}\n")
+		fmt.Fprintf(w, "if pe, ok := err.(*%s); ok && pe.Offset > pos {\nreturn nil, pos, err\n}\n", g.errorType())
+		fmt.Fprintf(w, "if s.at(pos) == 0 {\nreturn nil, pos, s.errorAt(pos, %s)\n}\n", g.errorVar(fmt.Sprintf(`Stream ended, expected %s`, n.desc.Label)))
+		fmt.Fprintf(w, "return nil, pos, s.errorAt(pos, %s)\n", g.errorVar(fmt.Sprintf(`Expected %s`, n.desc.Label)))
+
+	case c.KindNamed:
+		// rules with actions fail at their start, like the Transform of an action
+		fmt.Fprintf(w, "result, next, err := %s(pos)\n", child(0))
+		fmt.Fprintf(w, "action := s.actions[%d]\n", n.rule.index)
+		fmt.Fprintf(w, "if err != nil {\nif action != nil {\nnext = pos\n}\nreturn nil, pos, s.withRule(next, err, %s)\n}\n", strconv.Quote(n.rule.name))
+		fmt.Fprintf(w, "if action != nil {\nresult = action(result)\n}\n")
+		fmt.Fprintf(w, "return result, next, nil\n")
+	}
+}
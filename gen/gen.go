@@ -0,0 +1,302 @@
+// Package gen generates standalone recursive descent parsers in Go from
+// grammars built with the combinators of this module, like the ones loaded by
+// package peg. The generated code only depends on the standard library, it
+// works on a []rune and doesn't allocate parser states, but its results and
+// error messages are the same as the ones of the interpreted grammar: see
+// CheckAgreement to test that on a corpus.
+//
+// Grammars are introspected with combinators.Describe, so only the built-in
+// combinators that don't depend on the parser state can be generated.
+// ExpectPredicate parsers are generated for the predicates of package
+// combinators (Any, Digit, Letter...), for the character classes of
+// ExpectClass, like the ones of package peg, and for the ones in
+// Options.Predicates. Transforms are generated for
+// StringifyResult, Unwrap and for the transforms of whole Named rules, which
+// become actions of the generated parser. The parsers of semantic actions
+// written in Go can't be generated, load grammars without actions and attach
+// them to the generated parser instead.
+//
+// The cmd/parcomb-gen command generates parsers from PEG files and is meant
+// to be used with go generate.
+package gen
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// Options configure the generated code
+type Options struct {
+	// Package is the package name of the generated file
+	Package string
+	// Type is the name of the generated parser type, "Parser" by default. The
+	// error type is named after it, "ParseError" for "Parser" and
+	// "ArithParseError" for "ArithParser" or "Arith".
+	Type string
+	// Source is the name of the grammar file mentioned in the header of the
+	// generated file
+	Source string
+	// Rules are more parsers to generate besides the root, like the rules of
+	// a grammar not reachable from its start rule. The Named ones can be used
+	// with the ParseRule method of the generated parser.
+	Rules []c.Parser
+	// Predicates are Go expressions of type func(rune) bool to use for the
+	// ExpectPredicate parsers with the given description
+	Predicates map[string]string
+}
+
+// Generate writes the Go code of a parser for the grammar of root
+func Generate(w io.Writer, root c.Parser, opts Options) error {
+	if opts.Package == "" {
+		return fmt.Errorf(`Missing package name`)
+	}
+	if opts.Type == "" {
+		opts.Type = "Parser"
+	}
+
+	g := &generator{
+		opts:    opts,
+		nodes:   map[c.Parser]*node{},
+		errors:  map[string]string{},
+		imports: map[string]bool{"fmt": true, "strings": true},
+	}
+
+	start, err := g.node(root)
+	if err != nil {
+		return err
+	}
+	for _, parser := range opts.Rules {
+		if _, err := g.node(parser); err != nil {
+			return err
+		}
+	}
+
+	source, err := format.Source(g.emit(start))
+	if err != nil {
+		return fmt.Errorf(`Invalid generated code: %v`, err)
+	}
+
+	_, err = w.Write(source)
+	return err
+}
+
+// node is a parser of the grammar that gets its own function
+type node struct {
+	id   int
+	desc c.Description
+	// rule is the rule the node was found in, or the rule defined by a Named node
+	rule *rule
+	// children are the nodes of desc.Children
+	children []*node
+	// ignored are the children of SeqOf that are SeqIgnore
+	ignored []bool
+	// predicate is the Go condition on r of ExpectPredicate
+	predicate string
+}
+
+type rule struct {
+	name  string
+	index int
+	// ident is the name of the function of the rule
+	ident string
+	// action is true when the grammar transforms the result of the whole rule
+	action bool
+}
+
+type generator struct {
+	opts  Options
+	nodes map[c.Parser]*node
+	order []*node
+	rules []*rule
+	// rule is the rule being visited
+	rule *rule
+
+	// errors are the names of the variables of constant error messages
+	errors     map[string]string
+	errorOrder []string
+	predicates []string
+	imports    map[string]bool
+}
+
+// transparent kinds don't change results nor errors and get no function,
+// SeqIgnore only matters to its SeqOf
+var transparent = map[c.Kind]bool{
+	c.KindLazy:                true,
+	c.KindSeqIgnore:           true,
+	c.KindRestarableOneOrMore: true,
+	c.KindMemoize:             true,
+}
+
+func (g *generator) fail(desc c.Description, reason string) error {
+	what := desc.String()
+	if desc.Kind == c.KindLabel {
+		what = fmt.Sprintf("Label(%s)", desc.Label)
+	}
+
+	if g.rule == nil {
+		return fmt.Errorf(`%s can't be generated%s`, what, reason)
+	}
+
+	return fmt.Errorf(`%s in rule "%s" can't be generated%s`, what, g.rule.name, reason)
+}
+
+// node returns the node of a parser, visiting its children the first time
+func (g *generator) node(parser c.Parser) (*node, error) {
+	desc := c.Describe(parser)
+	if transparent[desc.Kind] {
+		return g.node(desc.Children[0])
+	}
+
+	comparable := reflect.TypeOf(parser).Comparable()
+	if comparable {
+		if n, ok := g.nodes[parser]; ok {
+			return n, nil
+		}
+	}
+
+	n := &node{id: len(g.order), desc: desc, rule: g.rule}
+	if comparable {
+		g.nodes[parser] = n
+	}
+	g.order = append(g.order, n)
+
+	switch desc.Kind {
+	case c.KindExpect, c.KindExpectAny, c.KindExpectString, c.KindEOF:
+		return n, nil
+
+	case c.KindExpectPredicate:
+		predicate, ok := g.predicate(parser, desc)
+		if !ok {
+			return nil, g.fail(desc, `, add its code to Options.Predicates`)
+		}
+		n.predicate = predicate
+		return n, nil
+
+	case c.KindNamed:
+		parent := g.rule
+		g.rule = g.newRule(desc.Label)
+		defer func() { g.rule = parent }()
+		n.rule = g.rule
+
+		body := desc.Children[0]
+		if inner := c.Describe(body); inner.Kind == c.KindTransform && inner.Text == "" {
+			g.rule.action = true
+			body = inner.Children[0]
+		}
+
+		child, err := g.node(body)
+		if err != nil {
+			return nil, err
+		}
+		n.children = []*node{child}
+		return n, nil
+
+	case c.KindTransform:
+		if desc.Text != "StringifyResult" && desc.Text != "Unwrap" {
+			return nil, g.fail(desc, `, only the transforms of whole rules are generated as actions`)
+		}
+
+	case c.KindSeqOf, c.KindAnyOf, c.KindRepeatUntil, c.KindOneOrMore, c.KindZeroOrMore,
+		c.KindOptional, c.KindNot, c.KindLookahead, c.KindLabel:
+
+	default:
+		return nil, g.fail(desc, "")
+	}
+
+	for _, child := range desc.Children {
+		childNode, err := g.node(child)
+		if err != nil {
+			return nil, err
+		}
+
+		n.children = append(n.children, childNode)
+		n.ignored = append(n.ignored, c.Describe(child).Kind == c.KindSeqIgnore)
+	}
+
+	return n, nil
+}
+
+func (g *generator) newRule(name string) *rule {
+	ident := "rule"
+	for _, r := range name {
+		if r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			ident += string(r)
+		} else {
+			ident += "_"
+		}
+	}
+
+	taken := map[string]bool{}
+	for _, other := range g.rules {
+		taken[other.ident] = true
+	}
+	for unique, i := ident, 2; taken[ident]; i++ {
+		ident = fmt.Sprintf("%s%d", unique, i)
+	}
+
+	r := &rule{name: name, index: len(g.rules), ident: ident}
+	g.rules = append(g.rules, r)
+	return r
+}
+
+// builtinPredicates are the conditions of the predicates of package combinators
+var builtinPredicates = map[c.Parser]string{
+	c.Any:         "true",
+	c.Digit:       "unicode.IsDigit(r)",
+	c.Letter:      "unicode.IsLetter(r)",
+	c.Space:       "unicode.IsSpace(r)",
+	c.InlineSpace: `r == ' ' || r == '\t'`,
+	c.Newline:     `r == '\n' || r == '\r'`,
+}
+
+// predicate returns the Go condition on r of an ExpectPredicate
+func (g *generator) predicate(parser c.Parser, desc c.Description) (string, bool) {
+	if code, ok := g.opts.Predicates[desc.Text]; ok {
+		name := fmt.Sprintf("%sPredicate%d", g.prefix(), len(g.predicates))
+		g.predicates = append(g.predicates, fmt.Sprintf("var %s = %s", name, code))
+		return name + "(r)", true
+	}
+
+	if code, ok := builtinPredicates[parser]; ok {
+		if strings.Contains(code, "unicode.") {
+			g.imports["unicode"] = true
+		}
+		return code, true
+	}
+
+	if desc.Class != nil {
+		return classCondition(*desc.Class), true
+	}
+
+	return "", false
+}
+
+// classCondition converts a character class, like the ones of package peg, to a condition on r
+func classCondition(class c.CharClass) string {
+	conditions := []string{}
+	for _, rr := range class.Ranges {
+		if rr.Lo == rr.Hi {
+			conditions = append(conditions, fmt.Sprintf("r == %s", strconv.QuoteRune(rr.Lo)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%s <= r && r <= %s", strconv.QuoteRune(rr.Lo), strconv.QuoteRune(rr.Hi)))
+		}
+	}
+
+	if len(conditions) == 0 {
+		conditions = []string{"false"}
+	}
+
+	condition := strings.Join(conditions, " || ")
+	if class.Negated {
+		return fmt.Sprintf("!(%s)", condition)
+	}
+
+	return condition
+}
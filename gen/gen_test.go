@@ -0,0 +1,104 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	word := c.Named("Word", c.StringifyResult(c.OneOrMore(c.Letter)))
+	list := c.Named("List", c.SeqOf(word, c.ZeroOrMore(c.SeqOf(c.SeqIgnore(c.Expect(',')), word)), c.EOF))
+
+	code := &bytes.Buffer{}
+	assert.Nil(t, Generate(code, list, Options{Package: "words", Type: "WordParser"}))
+
+	assert.Contains(t, code.String(), "package words\n")
+	assert.Contains(t, code.String(), "type WordParser struct {")
+	assert.Contains(t, code.String(), "type WordParseError struct {")
+	assert.Contains(t, code.String(), `var wordParserRuleNames = [2]string{"List", "Word"}`)
+	assert.Contains(t, code.String(), "unicode.IsLetter(r)")
+	assert.Contains(t, code.String(), "return wordParserStringify(result), next, nil")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	vowel := c.ExpectPredicate(func(r rune) bool { return strings.ContainsRune("aeiou", r) }, "vowel")
+
+	{
+		err := Generate(&bytes.Buffer{}, c.Expect('a'), Options{})
+		assert.EqualError(t, err, `Missing package name`)
+	}
+	{
+		err := Generate(&bytes.Buffer{}, c.Named("Vowels", c.OneOrMore(vowel)), Options{Package: "p"})
+		assert.EqualError(t, err, `ExpectPredicate(vowel) in rule "Vowels" can't be generated, add its code to Options.Predicates`)
+	}
+	{
+		err := Generate(&bytes.Buffer{}, c.Named("Vowels", c.OneOrMore(vowel)), Options{
+			Package:    "p",
+			Predicates: map[string]string{"vowel": `func(r rune) bool { return strings.ContainsRune("aeiou", r) }`},
+		})
+		assert.Nil(t, err)
+	}
+	{
+		err := Generate(&bytes.Buffer{}, c.Named("Number", c.SeqOf(c.Integer)), Options{Package: "p"})
+		assert.EqualError(t, err, `Transform in rule "Number" can't be generated, only the transforms of whole rules are generated as actions`)
+	}
+	{
+		err := Generate(&bytes.Buffer{}, c.SeqOf(c.Expect('a'), c.GetState()), Options{Package: "p"})
+		assert.EqualError(t, err, `GetState can't be generated`)
+	}
+}
+
+func TestClassCondition(t *testing.T) {
+	rr := func(lo, hi rune) c.RuneRange { return c.RuneRange{Lo: lo, Hi: hi} }
+
+	for _, test := range []struct {
+		class     c.CharClass
+		condition string
+	}{
+		{c.CharClass{Ranges: []c.RuneRange{rr('a', 'z'), rr('_', '_')}}, `'a' <= r && r <= 'z' || r == '_'`},
+		{c.CharClass{Negated: true, Ranges: []c.RuneRange{rr(']', ']'), rr('\n', '\n')}}, `!(r == ']' || r == '\n')`},
+		{c.CharClass{Ranges: []c.RuneRange{rr('-', '-'), rr('\'', '\''), rr('"', '"')}}, `r == '-' || r == '\'' || r == '"'`},
+		{c.CharClass{}, `false`},
+	} {
+		assert.Equal(t, test.condition, classCondition(test.class), test.class.String())
+	}
+
+	{
+		// predicates described like classes are not parsed as classes
+		digits := c.ExpectPredicate(func(r rune) bool { return '0' <= r && r <= '9' }, "[digits]")
+		err := Generate(&bytes.Buffer{}, c.Named("Digits", c.OneOrMore(digits)), Options{Package: "p"})
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCheckAgreement(t *testing.T) {
+	digits := c.StringifyResult(c.OneOrMore(c.Digit))
+	corpus := map[string]string{"a": "123", "b": "x"}
+
+	{
+		err := CheckAgreement(digits, func(input string) (interface{}, error) {
+			if input == "x" {
+				return nil, fmt.Errorf(`Expected "[digit]"`)
+			}
+			return input, nil
+		}, corpus)
+		assert.Nil(t, err)
+	}
+	{
+		err := CheckAgreement(digits, func(input string) (interface{}, error) {
+			return input, nil
+		}, corpus)
+		assert.EqualError(t, err, `Input b: the grammar returned the error Expected "[digit]" but the generated parser <nil>`)
+	}
+	{
+		err := CheckAgreement(digits, func(input string) (interface{}, error) {
+			return []interface{}{input}, nil
+		}, map[string]string{"a": "1"})
+		assert.EqualError(t, err, `Input a: the grammar returned "1" but the generated parser []interface {}{"1"}`)
+	}
+}
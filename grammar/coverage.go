@@ -0,0 +1,150 @@
+package grammar
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// WriteCoverage writes a table with the counts of the rules reachable from
+// root and of the alternatives in their bodies collected in a Coverage, rules
+// that are not Named have no counts. It ends with how many rules and
+// alternatives succeeded at least once.
+func WriteCoverage(w io.Writer, root c.Parser, cov *c.Coverage) error {
+	col := collect(root)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE / ALTERNATIVE\tATTEMPTS\tSUCCESSES")
+
+	rules, rulesCovered := 0, 0
+	alternatives, alternativesCovered := 0, 0
+
+	for _, rule := range col.rules {
+		if rule.named == nil {
+			fmt.Fprintf(tw, "%s\t-\t-\n", rule.Name)
+		} else {
+			count := cov.Rule(rule.named)
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", rule.Name, count.Attempts, count.Successes)
+
+			rules++
+			if count.Successes > 0 {
+				rulesCovered++
+			}
+		}
+
+		for _, choice := range choices(col.expr(rule.Body)) {
+			for i, child := range choice.children {
+				text, _ := renderEBNF(child, precChoice+1)
+				count := cov.Alternative(choice.choice, i)
+				fmt.Fprintf(tw, "  | %s\t%d\t%d\n", text, count.Attempts, count.Successes)
+
+				alternatives++
+				if count.Successes > 0 {
+					alternativesCovered++
+				}
+			}
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Rules succeeded: %d of %d, alternatives succeeded: %d of %d\n", rulesCovered, rules, alternativesCovered, alternatives)
+	return err
+}
+
+// Coverage returns the coverage table of the rules reachable from root, see WriteCoverage
+func Coverage(root c.Parser, cov *c.Coverage) string {
+	sb := &strings.Builder{}
+	WriteCoverage(sb, root, cov)
+	return sb.String()
+}
+
+// choices returns the choices of an expression with their AnyOf parsers, outermost first
+func choices(e *expr) []*expr {
+	result := []*expr{}
+	if e.kind == exprChoice && e.choice != nil {
+		result = append(result, e)
+	}
+	for _, child := range e.children {
+		result = append(result, choices(child)...)
+	}
+
+	return result
+}
+
+const coverageStyle = `body { font-family: sans-serif; }
+pre { font-size: 14px; line-height: 1.6; }
+.covered { background: #c8f0c8; }
+.attempted { background: #f8e8a0; }
+.uncovered { background: #f8c0c0; }`
+
+// WriteCoverageHTML writes an HTML page with the grammar reachable from root
+// in EBNF, as in WriteEBNF, where the names of the rules and the alternatives
+// are highlighted as covered when they succeeded, attempted when they only
+// failed and uncovered when they were never attempted. Their counts are shown
+// on hover.
+func WriteCoverageHTML(w io.Writer, root c.Parser, cov *c.Coverage) error {
+	col := collect(root)
+
+	span := func(count c.CoverageCount, text string) string {
+		class := "uncovered"
+		if count.Successes > 0 {
+			class = "covered"
+		} else if count.Attempts > 0 {
+			class = "attempted"
+		}
+
+		return fmt.Sprintf(`<span class="%s" title="attempted %d, succeeded %d">%s</span>`, class, count.Attempts, count.Successes, text)
+	}
+
+	style := ebnfStyle{
+		escape: html.EscapeString,
+		alternative: func(choice *expr, index int, text string) string {
+			if choice.choice == nil {
+				return text
+			}
+			return span(cov.Alternative(choice.choice, index), text)
+		},
+	}
+
+	width := 0
+	for _, rule := range col.rules {
+		if len(rule.Name) > width {
+			width = len(rule.Name)
+		}
+	}
+
+	body := &strings.Builder{}
+	for _, rule := range col.rules {
+		name := html.EscapeString(rule.Name)
+		if rule.named != nil {
+			name = span(cov.Rule(rule.named), name)
+		}
+
+		text, _ := style.render(col.expr(rule.Body), precChoice)
+		fmt.Fprintf(body, "%s%s ::= %s\n", name, strings.Repeat(" ", width-len(rule.Name)), text)
+	}
+
+	_, err := fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Grammar coverage</title>
+<style>
+%s
+</style>
+</head>
+<body>
+<pre>
+%s</pre>
+</body>
+</html>
+`, coverageStyle, body.String())
+	return err
+}
@@ -0,0 +1,79 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// WriteDOT writes the graph of the combinators reachable from root in
+// Graphviz DOT format. Named rules are drawn as bold boxes, labels as dashed
+// boxes and terminals as ellipses, Lazy references are replaced by their
+// targets so recursive rules become cycles.
+func WriteDOT(w io.Writer, root c.Parser) error {
+	d := &dotWriter{ids: map[c.Parser]int{}}
+	d.node(root)
+
+	_, err := fmt.Fprintf(w, "digraph grammar {\n%s}\n", d.sb.String())
+	return err
+}
+
+// DOT returns the graph of the combinators reachable from root, see WriteDOT
+func DOT(root c.Parser) string {
+	sb := &strings.Builder{}
+	WriteDOT(sb, root)
+	return sb.String()
+}
+
+type dotWriter struct {
+	sb   strings.Builder
+	ids  map[c.Parser]int
+	next int
+}
+
+func (d *dotWriter) node(parser c.Parser) int {
+	desc := c.Describe(parser)
+	for desc.Kind == c.KindLazy {
+		parser = desc.Children[0]
+		desc = c.Describe(parser)
+	}
+
+	if comparable(parser) {
+		if id, ok := d.ids[parser]; ok {
+			return id
+		}
+	}
+
+	d.next++
+	id := d.next
+	if comparable(parser) {
+		d.ids[parser] = id
+	}
+
+	attrs := "shape=box, style=rounded"
+	switch {
+	case desc.Kind == c.KindNamed:
+		attrs = "shape=box, style=bold"
+	case desc.Kind == c.KindLabel:
+		attrs = "shape=box, style=dashed"
+	case len(desc.Children) == 0:
+		attrs = "shape=ellipse"
+	}
+
+	fmt.Fprintf(&d.sb, "  n%d [label=%s, %s];\n", id, strconv.Quote(desc.String()), attrs)
+
+	for i, child := range desc.Children {
+		childID := d.node(child)
+
+		if len(desc.Children) > 1 {
+			fmt.Fprintf(&d.sb, "  n%d -> n%d [label=\"%d\"];\n", id, childID, i+1)
+		} else {
+			fmt.Fprintf(&d.sb, "  n%d -> n%d;\n", id, childID)
+		}
+	}
+
+	return id
+}
@@ -0,0 +1,189 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// operator precedences, higher binds tighter
+const (
+	precChoice = iota
+	precSequence
+	precPostfix
+	precAtom
+)
+
+// WriteEBNF writes the rules reachable from root in W3C EBNF notation (the
+// one of the XML specification) extended with the "!" and "&" predicates of
+// PEGs for lookaheads. Terminals described in prose, like
+// predicates and end of stream, are written as <description>.
+func WriteEBNF(w io.Writer, root c.Parser) error {
+	col := collect(root)
+
+	width := 0
+	for _, rule := range col.rules {
+		if len(rule.Name) > width {
+			width = len(rule.Name)
+		}
+	}
+
+	for _, rule := range col.rules {
+		body, _ := renderEBNF(col.expr(rule.Body), precChoice)
+		if _, err := fmt.Fprintf(w, "%-*s ::= %s\n", width, rule.Name, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EBNF returns the rules reachable from root in W3C EBNF notation, see WriteEBNF
+func EBNF(root c.Parser) string {
+	sb := &strings.Builder{}
+	WriteEBNF(sb, root)
+	return sb.String()
+}
+
+// renderEBNF renders an expression that is going to be placed in a context
+// of the given precedence, parenthesizing it if needed
+func renderEBNF(e *expr, prec int) (string, int) {
+	return ebnfStyle{}.render(e, prec)
+}
+
+// ebnfStyle decorates the rendered EBNF, like the HTML of coverage reports,
+// the zero value renders plain text
+type ebnfStyle struct {
+	// escape escapes the text of the terminals and of the operators
+	escape func(string) string
+	// alternative decorates the rendered alternatives of choices
+	alternative func(choice *expr, index int, text string) string
+}
+
+func (style ebnfStyle) render(e *expr, prec int) (string, int) {
+	escape := style.escape
+	if escape == nil {
+		escape = func(s string) string { return s }
+	}
+
+	var text string
+	var own int
+
+	switch e.kind {
+	case exprEmpty:
+		return escape(`""`), precAtom
+	case exprLiteral:
+		text, own = ebnfLiteral(e.text)
+		text = escape(text)
+	case exprCharset:
+		text, own = escape(ebnfCharset(e.text)), precAtom
+	case exprSpecial:
+		text, own = escape("<"+e.text+">"), precAtom
+	case exprReference:
+		text, own = escape(e.text), precAtom
+
+	case exprSequence, exprChoice:
+		sep, own2 := " ", precSequence
+		if e.kind == exprChoice {
+			sep, own2 = " | ", precChoice
+		}
+
+		parts := []string{}
+		for i, child := range e.children {
+			part, _ := style.render(child, own2+1)
+			if e.kind == exprChoice && style.alternative != nil {
+				part = style.alternative(e, i, part)
+			}
+			parts = append(parts, part)
+		}
+		text, own = strings.Join(parts, escape(sep)), own2
+
+	case exprOptional, exprZeroOrMore, exprOneOrMore:
+		child, _ := style.render(e.children[0], precAtom)
+		text, own = child+escape(map[exprKind]string{exprOptional: "?", exprZeroOrMore: "*", exprOneOrMore: "+"}[e.kind]), precPostfix
+
+	case exprNot, exprLookahead:
+		child, _ := style.render(e.children[0], precAtom)
+		text, own = escape(map[exprKind]string{exprNot: "!", exprLookahead: "&"}[e.kind])+child, precPostfix
+
+	case exprUntil:
+		child, _ := style.render(e.children[0], precSequence)
+		terminator, _ := style.render(e.children[1], precSequence)
+		text, own = escape("(")+child+escape(" - ")+terminator+escape(")*"), precPostfix
+	}
+
+	if own < prec {
+		return escape("(") + text + escape(")"), precAtom
+	}
+
+	return text, own
+}
+
+// ebnfLiteral quotes a literal string, runes that can't be quoted become character references
+func ebnfLiteral(text string) (string, int) {
+	parts := []string{}
+	run := []rune{}
+
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+
+		s := string(run)
+		if strings.ContainsRune(s, '"') {
+			parts = append(parts, "'"+s+"'")
+		} else {
+			parts = append(parts, `"`+s+`"`)
+		}
+		run = run[:0]
+	}
+
+	for _, r := range text {
+		if !unicode.IsPrint(r) {
+			flush()
+			parts = append(parts, charRef(r))
+			continue
+		}
+
+		current := string(run)
+		if r == '"' && strings.ContainsRune(current, '\'') || r == '\'' && strings.ContainsRune(current, '"') {
+			flush()
+		}
+		run = append(run, r)
+	}
+	flush()
+
+	if len(parts) == 1 {
+		return parts[0], precAtom
+	}
+
+	return strings.Join(parts, " "), precSequence
+}
+
+func ebnfCharset(text string) string {
+	runes := []rune(text)
+	if len(runes) == 1 {
+		literal, _ := ebnfLiteral(text)
+		return literal
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("[")
+	for _, r := range runes {
+		if !unicode.IsPrint(r) || strings.ContainsRune(`]^-\`, r) {
+			sb.WriteString(charRef(r))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteString("]")
+
+	return sb.String()
+}
+
+func charRef(r rune) string {
+	return fmt.Sprintf("#x%X", r)
+}
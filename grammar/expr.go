@@ -0,0 +1,108 @@
+package grammar
+
+import (
+	c "github.com/aziis98/parser-combinators"
+)
+
+type exprKind int
+
+const (
+	exprEmpty exprKind = iota
+	exprLiteral
+	exprCharset
+	exprSpecial
+	exprReference
+	exprSequence
+	exprChoice
+	exprOptional
+	exprZeroOrMore
+	exprOneOrMore
+	exprUntil
+	exprNot
+	exprLookahead
+)
+
+// expr is the simplified form of a parser shared by the EBNF and railroad
+// renderers: transformations, ignored results and other wrappers that don't
+// change the accepted language are dropped
+type expr struct {
+	kind     exprKind
+	text     string
+	children []*expr
+	// choice is the AnyOf parser of a choice, its alternatives are the children
+	choice c.Parser
+}
+
+// expr converts a parser to its expression, rules reached from it become references
+func (col *collector) expr(parser c.Parser) *expr {
+	desc := c.Describe(parser)
+
+	switch desc.Kind {
+	case c.KindExpect, c.KindExpectString, c.KindExpectTokenText:
+		return &expr{kind: exprLiteral, text: desc.Text}
+	case c.KindExpectAny:
+		return &expr{kind: exprCharset, text: desc.Text}
+	case c.KindExpectPredicate, c.KindQuotedString, c.KindExpectToken, c.KindOpaque:
+		if desc.Text == "" {
+			return &expr{kind: exprSpecial, text: string(desc.Kind)}
+		}
+		return &expr{kind: exprSpecial, text: desc.Text}
+	case c.KindEOF, c.KindTrivia, c.KindFromState:
+		return &expr{kind: exprSpecial, text: string(desc.Kind)}
+	case c.KindGetState, c.KindSetState:
+		return &expr{kind: exprEmpty}
+
+	case c.KindSeqOf:
+		return col.exprList(exprSequence, desc.Children)
+	case c.KindAnyOf:
+		e := col.exprList(exprChoice, desc.Children)
+		if e.kind == exprChoice {
+			e.choice = parser
+		}
+		return e
+	case c.KindOptional:
+		return &expr{kind: exprOptional, children: []*expr{col.expr(desc.Children[0])}}
+	case c.KindZeroOrMore:
+		return &expr{kind: exprZeroOrMore, children: []*expr{col.expr(desc.Children[0])}}
+	case c.KindOneOrMore, c.KindRestarableOneOrMore:
+		return &expr{kind: exprOneOrMore, children: []*expr{col.expr(desc.Children[0])}}
+	case c.KindNot:
+		return &expr{kind: exprNot, children: []*expr{col.expr(desc.Children[0])}}
+	case c.KindLookahead:
+		return &expr{kind: exprLookahead, children: []*expr{col.expr(desc.Children[0])}}
+	case c.KindRepeatUntil:
+		return &expr{kind: exprUntil, children: []*expr{col.expr(desc.Children[0]), col.expr(desc.Children[1])}}
+
+	case c.KindNamed, c.KindLazy:
+		if rule := col.reference(parser); rule != nil {
+			return &expr{kind: exprReference, text: rule.Name}
+		}
+	}
+
+	if len(desc.Children) == 1 {
+		return col.expr(desc.Children[0])
+	}
+
+	return &expr{kind: exprSpecial, text: desc.String()}
+}
+
+func (col *collector) exprList(kind exprKind, parsers []c.Parser) *expr {
+	children := []*expr{}
+	for _, p := range parsers {
+		child := col.expr(p)
+		if kind == exprSequence && child.kind == exprEmpty {
+			continue
+		}
+
+		children = append(children, child)
+	}
+
+	switch len(children) {
+	case 0:
+		return &expr{kind: exprEmpty}
+	case 1:
+		return children[0]
+	}
+
+	return &expr{kind: kind, children: children}
+}
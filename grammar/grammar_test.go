@@ -0,0 +1,119 @@
+package grammar
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/stretchr/testify/assert"
+)
+
+// expression is a small recursive grammar of sums of parenthesized terms
+var expression c.Parser
+
+func init() {
+	number := c.Named("Number", c.StringifyResult(c.OneOrMore(c.Digit)))
+
+	term := c.Named("Term", c.AnyOf(
+		number,
+		c.SeqOf(
+			c.SeqIgnore(c.Expect('(')),
+			c.Lazy(func() c.Parser { return expression }),
+			c.SeqIgnore(c.Expect(')')),
+		),
+	))
+
+	expression = c.Named("Expression", c.SeqOf(
+		term,
+		c.ZeroOrMore(c.SeqOf(c.ExpectAny([]rune("+-")), term)),
+		c.Optional(c.ExpectString([]rune(`"'`))),
+		c.RepeatUntil(c.Any, c.Expect('\n')),
+	))
+}
+
+func TestRules(t *testing.T) {
+	names := []string{}
+	for _, rule := range Rules(expression) {
+		names = append(names, rule.Name)
+	}
+	assert.Equal(t, []string{"Expression", "Term", "Number"}, names)
+
+	names = []string{}
+	for _, rule := range Rules(c.SeqOf(c.Lazy(func() c.Parser { return c.Expect('a') }), expression)) {
+		names = append(names, rule.Name)
+	}
+	assert.Equal(t, []string{"Start", "Rule1", "Expression", "Term", "Number"}, names)
+}
+
+func TestEBNF(t *testing.T) {
+	assert.Equal(t, `Expression ::= Term ([+#x2D] Term)* ('"' "'")? (<any> - #xA)*
+Term       ::= Number | "(" Expression ")"
+Number     ::= <digit>+
+`, EBNF(expression))
+
+	assert.Equal(t, "Start ::= (!\"*/\" <any>)* &\"*/\"\n", EBNF(c.SeqOf(c.ZeroOrMore(c.SeqOf(c.Not(c.ExpectString([]rune("*/"))), c.Any)), c.Lookahead(c.ExpectString([]rune("*/"))))))
+	assert.Equal(t, "Start ::= \"a\" | <FromState> <EOF>\n", EBNF(c.AnyOf(c.Expect('a'), c.SeqOf(c.FromState(nil), c.EOF))))
+}
+
+func TestDOT(t *testing.T) {
+	dot := DOT(expression)
+
+	assert.True(t, strings.HasPrefix(dot, "digraph grammar {\n  n1 [label=\"Expression\", shape=box, style=bold];\n"))
+	assert.Contains(t, dot, `[label="Expect('(')", shape=ellipse];`)
+	assert.Contains(t, dot, `n9 -> n1 [label="2"];`, "the recursive reference goes back to the root rule")
+	assert.True(t, strings.HasSuffix(dot, "}\n"))
+}
+
+func TestRailroad(t *testing.T) {
+	svg := Railroad(expression)
+
+	titles := []string{}
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	inTitle := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			inTitle = tok.Name.Local == "text" && len(tok.Attr) > 0 && tok.Attr[0].Value == "title"
+		case xml.CharData:
+			if inTitle {
+				titles = append(titles, string(tok))
+			}
+		case xml.EndElement:
+			inTitle = false
+		}
+	}
+
+	assert.Equal(t, []string{"Expression", "Term", "Number"}, titles)
+	assert.Contains(t, svg, `<text x="`)
+	assert.Contains(t, svg, `>until #xA</text>`)
+}
+
+func TestCoverage(t *testing.T) {
+	cov := c.NewCoverage()
+	for _, input := range []string{"1+2\n", "x"} {
+		c.ParseRuneReader(expression, strings.NewReader(input), c.WithCoverage(cov))
+	}
+
+	assert.Equal(t, `RULE / ALTERNATIVE      ATTEMPTS  SUCCESSES
+Expression              2         1
+Term                    3         2
+  | Number              3         2
+  | "(" Expression ")"  1         0
+Number                  3         2
+Rules succeeded: 3 of 3, alternatives succeeded: 1 of 2
+`, Coverage(expression, cov))
+
+	page := &strings.Builder{}
+	assert.Nil(t, WriteCoverageHTML(page, expression, cov))
+	assert.Contains(t, page.String(), `<span class="covered" title="attempted 3, succeeded 2">Term</span>       ::= `)
+	assert.Contains(t, page.String(), `<span class="attempted" title="attempted 1, succeeded 0">&#34;(&#34; Expression &#34;)&#34;</span>`)
+	assert.Contains(t, page.String(), `Number</span>     ::= &lt;digit&gt;+`)
+}
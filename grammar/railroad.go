@@ -0,0 +1,279 @@
+package grammar
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// railroad layout constants, in pixels
+const (
+	rrArc       = 10
+	rrGap       = 10
+	rrBoxHalf   = 11
+	rrCharWidth = 8
+	rrPadding   = 10
+	rrMargin    = 20
+	rrTitle     = 20
+)
+
+const rrStyle = `path { stroke: #333; stroke-width: 2; fill: none; }
+rect { stroke: #333; stroke-width: 2; fill: #fffbe6; }
+rect.nonterminal { fill: #e6f0ff; }
+rect.comment { stroke-dasharray: 4 3; fill: none; }
+text { font-family: monospace; font-size: 14px; text-anchor: middle; }
+text.title { font-weight: bold; text-anchor: start; }`
+
+// rrItem is an element of a railroad diagram, its main line enters on the
+// left and exits on the right at the same height
+type rrItem interface {
+	// size returns the width and the space above and below the main line
+	size() (width, up, down int)
+	// draw draws the item with the main line entering at x, y
+	draw(sb *strings.Builder, x, y int)
+}
+
+type rrBox struct {
+	text  string
+	class string
+}
+
+func (b *rrBox) size() (int, int, int) {
+	return utf8.RuneCountInString(b.text)*rrCharWidth + 2*rrPadding, rrBoxHalf, rrBoxHalf
+}
+
+func (b *rrBox) draw(sb *strings.Builder, x, y int) {
+	width, _, _ := b.size()
+
+	rx := 0
+	if b.class == "terminal" {
+		rx = rrBoxHalf
+	}
+
+	fmt.Fprintf(sb, `<rect class="%s" x="%d" y="%d" width="%d" height="%d" rx="%d"/>`+"\n", b.class, x, y-rrBoxHalf, width, 2*rrBoxHalf, rx)
+	fmt.Fprintf(sb, `<text x="%d" y="%d">%s</text>`+"\n", x+width/2, y+5, html.EscapeString(b.text))
+}
+
+type rrSkip struct{}
+
+func (s *rrSkip) size() (int, int, int) {
+	return 0, 0, 0
+}
+
+func (s *rrSkip) draw(sb *strings.Builder, x, y int) {}
+
+type rrSequence struct {
+	items []rrItem
+}
+
+func (s *rrSequence) size() (int, int, int) {
+	width, up, down := 0, 0, 0
+	for i, item := range s.items {
+		w, u, d := item.size()
+		width += w
+		if i > 0 {
+			width += rrGap
+		}
+		up, down = max(up, u), max(down, d)
+	}
+
+	return width, up, down
+}
+
+func (s *rrSequence) draw(sb *strings.Builder, x, y int) {
+	for i, item := range s.items {
+		if i > 0 {
+			fmt.Fprintf(sb, `<path d="M%d %d h%d"/>`+"\n", x, y, rrGap)
+			x += rrGap
+		}
+
+		item.draw(sb, x, y)
+		w, _, _ := item.size()
+		x += w
+	}
+}
+
+// rrChoice draws the first alternative on the main line and the others below it
+type rrChoice struct {
+	items []rrItem
+}
+
+// offsets returns the distance of the main line of each alternative from the main line of the choice
+func (ch *rrChoice) offsets() ([]int, int) {
+	offsets := []int{0}
+	_, _, bottom := ch.items[0].size()
+
+	for _, item := range ch.items[1:] {
+		_, u, d := item.size()
+		offset := max(bottom+rrGap+u, 2*rrArc)
+		offsets = append(offsets, offset)
+		bottom = offset + d
+	}
+
+	return offsets, bottom
+}
+
+func (ch *rrChoice) inner() int {
+	width := 0
+	for _, item := range ch.items {
+		w, _, _ := item.size()
+		width = max(width, w)
+	}
+
+	return width
+}
+
+func (ch *rrChoice) size() (int, int, int) {
+	_, up, _ := ch.items[0].size()
+	_, down := ch.offsets()
+	return ch.inner() + 4*rrArc, up, down
+}
+
+func (ch *rrChoice) draw(sb *strings.Builder, x, y int) {
+	inner := ch.inner()
+	offsets, _ := ch.offsets()
+
+	for i, item := range ch.items {
+		w, _, _ := item.size()
+		yi := y + offsets[i]
+
+		if i == 0 {
+			fmt.Fprintf(sb, `<path d="M%d %d h%d"/>`+"\n", x, y, 2*rrArc)
+		} else {
+			fmt.Fprintf(sb, `<path d="M%d %d a%d %d 0 0 1 %d %d V%d a%d %d 0 0 0 %d %d"/>`+"\n",
+				x, y, rrArc, rrArc, rrArc, rrArc, yi-rrArc, rrArc, rrArc, rrArc, rrArc)
+		}
+
+		item.draw(sb, x+2*rrArc, yi)
+
+		right := x + 2*rrArc + inner
+		if i == 0 {
+			fmt.Fprintf(sb, `<path d="M%d %d H%d"/>`+"\n", x+2*rrArc+w, yi, right+2*rrArc)
+		} else {
+			fmt.Fprintf(sb, `<path d="M%d %d H%d a%d %d 0 0 0 %d %d V%d a%d %d 0 0 1 %d %d"/>`+"\n",
+				x+2*rrArc+w, yi, right, rrArc, rrArc, rrArc, -rrArc, y+rrArc, rrArc, rrArc, rrArc, -rrArc)
+		}
+	}
+}
+
+// rrLoop draws an item with a line going back from its end to its start below it
+type rrLoop struct {
+	item rrItem
+}
+
+func (l *rrLoop) size() (int, int, int) {
+	w, u, d := l.item.size()
+	return w + 2*rrArc, u, max(d+rrGap, 2*rrArc)
+}
+
+func (l *rrLoop) draw(sb *strings.Builder, x, y int) {
+	w, _, _ := l.item.size()
+	_, _, down := l.size()
+
+	fmt.Fprintf(sb, `<path d="M%d %d h%d"/>`+"\n", x, y, rrArc)
+	l.item.draw(sb, x+rrArc, y)
+	fmt.Fprintf(sb, `<path d="M%d %d h%d"/>`+"\n", x+rrArc+w, y, rrArc)
+
+	fmt.Fprintf(sb, `<path d="M%d %d a%d %d 0 0 1 %d %d V%d a%d %d 0 0 1 %d %d H%d a%d %d 0 0 1 %d %d V%d a%d %d 0 0 1 %d %d"/>`+"\n",
+		x+rrArc+w, y, rrArc, rrArc, rrArc, rrArc,
+		y+down-rrArc, rrArc, rrArc, -rrArc, rrArc,
+		x+rrArc, rrArc, rrArc, -rrArc, -rrArc,
+		y+rrArc, rrArc, rrArc, rrArc, -rrArc)
+}
+
+func railroadItem(e *expr) rrItem {
+	switch e.kind {
+	case exprLiteral, exprCharset:
+		text, _ := renderEBNF(e, precAtom)
+		return &rrBox{text, "terminal"}
+	case exprSpecial:
+		return &rrBox{"<" + e.text + ">", "terminal"}
+	case exprReference:
+		return &rrBox{e.text, "nonterminal"}
+
+	case exprSequence:
+		items := []rrItem{}
+		for _, child := range e.children {
+			items = append(items, railroadItem(child))
+		}
+		return &rrSequence{items}
+	case exprChoice:
+		items := []rrItem{}
+		for _, child := range e.children {
+			items = append(items, railroadItem(child))
+		}
+		return &rrChoice{items}
+
+	case exprOptional:
+		return &rrChoice{[]rrItem{&rrSkip{}, railroadItem(e.children[0])}}
+	case exprOneOrMore:
+		return &rrLoop{railroadItem(e.children[0])}
+	case exprZeroOrMore:
+		return &rrChoice{[]rrItem{&rrSkip{}, &rrLoop{railroadItem(e.children[0])}}}
+	case exprNot, exprLookahead:
+		child, _ := renderEBNF(e.children[0], precChoice)
+		return &rrBox{map[exprKind]string{exprNot: "not ", exprLookahead: "followed by "}[e.kind] + child, "comment"}
+	case exprUntil:
+		terminator, _ := renderEBNF(e.children[1], precChoice)
+		return &rrSequence{[]rrItem{
+			&rrChoice{[]rrItem{&rrSkip{}, &rrLoop{railroadItem(e.children[0])}}},
+			&rrBox{"until " + terminator, "comment"},
+		}}
+	}
+
+	return &rrSkip{}
+}
+
+// WriteRailroad writes an SVG document with a railroad diagram for each rule
+// reachable from root
+func WriteRailroad(w io.Writer, root c.Parser) error {
+	col := collect(root)
+
+	body := &strings.Builder{}
+	width, y := 0, rrMargin
+
+	for _, rule := range col.rules {
+		item := railroadItem(col.expr(rule.Body))
+		w, up, down := item.size()
+
+		fmt.Fprintf(body, `<text class="title" x="%d" y="%d">%s</text>`+"\n", rrMargin, y+14, html.EscapeString(rule.Name))
+
+		main := y + rrTitle + rrGap + up
+		x := rrMargin
+
+		fmt.Fprintf(body, `<path d="M%d %d v%d M%d %d h%d"/>`+"\n", x, main-rrBoxHalf, 2*rrBoxHalf, x, main, rrGap)
+		item.draw(body, x+rrGap, main)
+		end := x + rrGap + w
+		fmt.Fprintf(body, `<path d="M%d %d h%d M%d %d v%d"/>`+"\n", end, main, rrGap, end+rrGap, main-rrBoxHalf, 2*rrBoxHalf)
+
+		width = max(width, end+rrGap+rrMargin)
+		y = main + down + rrMargin + rrGap
+	}
+
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">
+<style>
+%s
+</style>
+%s</svg>
+`, width, y, width, y, rrStyle, body.String())
+	return err
+}
+
+// Railroad returns an SVG document with the railroad diagrams of the rules reachable from root, see WriteRailroad
+func Railroad(root c.Parser) string {
+	sb := &strings.Builder{}
+	WriteRailroad(sb, root)
+	return sb.String()
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
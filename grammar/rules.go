@@ -0,0 +1,123 @@
+// Package grammar renders parsers built with the combinators of this module
+// as EBNF, Graphviz DOT graphs, SVG railroad diagrams and coverage reports, using the
+// Descriptions of the built-in combinators.
+package grammar
+
+import (
+	"fmt"
+	"reflect"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// Rule is a production of a grammar
+type Rule struct {
+	Name string
+	// Body is the parser the rule stands for, without its Named wrapper
+	Body c.Parser
+
+	// named is the Named parser of the rule, if any
+	named c.Parser
+}
+
+// collector finds the rules reachable from a parser: Named parsers and the
+// targets of Lazy references, that are the only way to build recursive
+// grammars
+type collector struct {
+	rules  []*Rule
+	byNode map[c.Parser]*Rule
+	names  map[string]int
+
+	unnamed int
+}
+
+// Rules returns the rules reachable from root, root first. If the root is not
+// Named its rule is called "Start", unnamed targets of Lazy references are
+// called "Rule1", "Rule2"...
+func Rules(root c.Parser) []*Rule {
+	return collect(root).rules
+}
+
+func collect(root c.Parser) *collector {
+	col := &collector{byNode: map[c.Parser]*Rule{}, names: map[string]int{}}
+
+	if desc := c.Describe(root); desc.Kind == c.KindLazy {
+		root = desc.Children[0]
+	}
+
+	if desc := c.Describe(root); desc.Kind == c.KindNamed {
+		col.ruleFor(root)
+	} else {
+		col.add(root, "Start", root)
+	}
+
+	for i := 0; i < len(col.rules); i++ {
+		col.walk(col.rules[i].Body)
+	}
+
+	return col
+}
+
+func (col *collector) add(node c.Parser, name string, body c.Parser) *Rule {
+	col.names[name]++
+	if n := col.names[name]; n > 1 {
+		name = fmt.Sprintf("%s_%d", name, n)
+	}
+
+	rule := &Rule{Name: name, Body: body}
+	if c.Describe(node).Kind == c.KindNamed {
+		rule.named = node
+	}
+	col.rules = append(col.rules, rule)
+	if comparable(node) {
+		col.byNode[node] = rule
+	}
+
+	return rule
+}
+
+// ruleFor returns the rule of a Named parser or of the target of a Lazy
+// reference, adding it if it's the first time it's seen
+func (col *collector) ruleFor(node c.Parser) *Rule {
+	if comparable(node) {
+		if rule, ok := col.byNode[node]; ok {
+			return rule
+		}
+	}
+
+	desc := c.Describe(node)
+	if desc.Kind == c.KindNamed {
+		return col.add(node, desc.Label, desc.Children[0])
+	}
+
+	col.unnamed++
+	return col.add(node, fmt.Sprintf("Rule%d", col.unnamed), node)
+}
+
+// reference returns the rule referenced by a child parser, if any
+func (col *collector) reference(child c.Parser) *Rule {
+	desc := c.Describe(child)
+
+	switch desc.Kind {
+	case c.KindNamed:
+		return col.ruleFor(child)
+	case c.KindLazy:
+		if comparable(desc.Children[0]) {
+			return col.ruleFor(desc.Children[0])
+		}
+	}
+
+	return nil
+}
+
+func (col *collector) walk(parser c.Parser) {
+	for _, child := range c.Describe(parser).Children {
+		if col.reference(child) == nil {
+			col.walk(child)
+		}
+	}
+}
+
+func comparable(p c.Parser) bool {
+	return reflect.TypeOf(p).Comparable()
+}
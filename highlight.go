@@ -0,0 +1,113 @@
+package combinators
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// HighlightToken is a span of the input highlighted with the class of the
+// innermost Label containing it, the text outside Labels has an empty Class
+type HighlightToken struct {
+	// Start and End are the offsets in runes of the span
+	Start, End int
+	Text       string
+	Class      string
+}
+
+// Highlight parses the input and splits it into tokens classified by the
+// labels of the Labels that matched them, like Label(ident, "variable") or
+// Label(skipper.Symbol("if"), "keyword"). The trivia skipped after a labeled
+// Lexeme is not part of its span and the Labels inside a Lexeme are not
+// recorded. The tokens cover the whole input, the text after the consumed
+// part is left without a class.
+func Highlight(parser Parser, input string, opts ...ParseOption) ([]HighlightToken, error) {
+	_, root, err := ParseCST(parser, strings.NewReader(input), append([]ParseOption{WithCSTLabels()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	text := []rune(input)
+	classes := make([]string, len(text))
+
+	var paint func(n *CSTNode)
+	paint = func(n *CSTNode) {
+		if n.Label {
+			if tokens := n.Tokens(); len(tokens) > 0 {
+				for i := tokens[0].Start; i < tokens[len(tokens)-1].End; i++ {
+					classes[i] = n.Kind
+				}
+			}
+		}
+
+		for _, child := range n.Children {
+			paint(child)
+		}
+	}
+	paint(root)
+
+	tokens := []HighlightToken{}
+	for start := 0; start < len(text); {
+		end := start + 1
+		for end < len(text) && classes[end] == classes[start] {
+			end++
+		}
+
+		tokens = append(tokens, HighlightToken{start, end, string(text[start:end]), classes[start]})
+		start = end
+	}
+
+	return tokens, nil
+}
+
+// WriteHighlightHTML writes the tokens as HTML, the classified ones as
+// `<span class="...">` elements to style with CSS
+func WriteHighlightHTML(w io.Writer, tokens []HighlightToken) error {
+	for _, token := range tokens {
+		var err error
+		if token.Class == "" {
+			_, err = io.WriteString(w, html.EscapeString(token.Text))
+		} else {
+			_, err = fmt.Fprintf(w, `<span class="%s">%s</span>`, html.EscapeString(token.Class), html.EscapeString(token.Text))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DefaultANSIColors are the terminal colors of common classes as SGR parameters
+var DefaultANSIColors = map[string]string{
+	"keyword":  "1;35",
+	"operator": "33",
+	"string":   "32",
+	"number":   "36",
+	"comment":  "90",
+	"variable": "34",
+	"function": "1;34",
+	"type":     "1;36",
+}
+
+// WriteHighlightANSI writes the tokens colored with ANSI escape sequences,
+// colors maps the classes to SGR parameters like "1;34" for bold blue and the
+// tokens of other classes are written as they are
+func WriteHighlightANSI(w io.Writer, tokens []HighlightToken, colors map[string]string) error {
+	for _, token := range tokens {
+		var err error
+		if color, ok := colors[token.Class]; ok && token.Class != "" {
+			_, err = fmt.Fprintf(w, "\x1b[%sm%s\x1b[0m", color, token.Text)
+		} else {
+			_, err = io.WriteString(w, token.Text)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	skipper := &Skipper{LineComments: []string{"#"}}
+	number := Label(skipper.Lexeme(StringifyResult(OneOrMore(Digit))), "number")
+	call := Label(SeqOf(
+		Label(skipper.Lexeme(StringifyResult(OneOrMore(Letter))), "function"),
+		skipper.Symbol("("), number, skipper.Symbol(")"),
+	), "call")
+	let := SeqOf(
+		Label(skipper.Symbol("let"), "keyword"),
+		Label(skipper.Lexeme(StringifyResult(OneOrMore(Letter))), "variable"),
+		Label(skipper.Symbol("="), "operator"),
+		// the failed alternative is not highlighted
+		AnyOf(SeqOf(number, Label(skipper.Symbol("!"), "operator")), call, number),
+	)
+	program := SeqOf(SeqIgnore(skipper.Trivia()), OneOrMore(let))
+
+	tokens, err := Highlight(program, "let x = f(1) # one\nlet y = 2\n?")
+	assert.Nil(t, err)
+	assert.Equal(t, []HighlightToken{
+		{0, 3, "let", "keyword"},
+		{3, 4, " ", ""},
+		{4, 5, "x", "variable"},
+		{5, 6, " ", ""},
+		{6, 7, "=", "operator"},
+		{7, 8, " ", ""},
+		{8, 9, "f", "function"},
+		{9, 10, "(", "call"},
+		{10, 11, "1", "number"},
+		{11, 12, ")", "call"},
+		{12, 19, " # one\n", ""},
+		{19, 22, "let", "keyword"},
+		{22, 23, " ", ""},
+		{23, 24, "y", "variable"},
+		{24, 25, " ", ""},
+		{25, 26, "=", "operator"},
+		{26, 27, " ", ""},
+		{27, 28, "2", "number"},
+		{28, 30, "\n?", ""},
+	}, tokens)
+
+	sb := &strings.Builder{}
+	assert.Nil(t, WriteHighlightHTML(sb, tokens[2:7]))
+	assert.Equal(t, `<span class="variable">x</span> <span class="operator">=</span> <span class="function">f</span>`, sb.String())
+
+	sb.Reset()
+	assert.Nil(t, WriteHighlightHTML(sb, []HighlightToken{{Text: "a<b", Class: `"x"`}, {Text: "&"}}))
+	assert.Equal(t, `<span class="&#34;x&#34;">a&lt;b</span>&amp;`, sb.String())
+
+	sb.Reset()
+	assert.Nil(t, WriteHighlightANSI(sb, tokens[:3], map[string]string{"keyword": "1;35"}))
+	assert.Equal(t, "\x1b[1;35mlet\x1b[0m x", sb.String())
+
+	_, err = Highlight(program, "let = 1")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,176 @@
+package combinators
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// IncrementalParser reparses a text after each edit reusing the results of
+// the Named rules that didn't examine the edited region, like the blocks of
+// a document far from the cursor of an editor. The results are the same of
+// a full reparse as long as the results of the rules don't depend on their
+// absolute position, for example by storing offsets or lines.
+type IncrementalParser struct {
+	parser Parser
+	opts   []ParseOption
+	text   []rune
+
+	rules  map[incrementalKey]*incrementalEntry
+	reused int
+}
+
+// incrementalKey identifies the application of a rule at a position with an
+// indentation, trivia mode and user state
+type incrementalKey struct {
+	rule     Parser
+	cursor   int
+	indents  string
+	verbatim bool
+	user     interface{}
+}
+
+// incrementalEntry is a successful application of a rule, it examined the
+// runes from its start to furthest included. The entries stay valid as the
+// text changes, like in a packrat memo table there is at most one for each
+// rule application.
+type incrementalEntry struct {
+	result   interface{}
+	end      int
+	furthest int
+}
+
+// NewIncrementalParser creates an IncrementalParser, the options are used for every parse
+func NewIncrementalParser(parser Parser, opts ...ParseOption) *IncrementalParser {
+	return &IncrementalParser{parser: parser, opts: opts, rules: map[incrementalKey]*incrementalEntry{}}
+}
+
+// Text returns the current text
+func (p *IncrementalParser) Text() string {
+	return string(p.text)
+}
+
+// Reused returns the number of rule applications reused by the last parse
+func (p *IncrementalParser) Reused() int {
+	return p.reused
+}
+
+// Parse parses a whole new text
+func (p *IncrementalParser) Parse(text string) (interface{}, error) {
+	p.text = []rune(text)
+	p.rules = map[incrementalKey]*incrementalEntry{}
+
+	return p.parse()
+}
+
+// Edit replaces the runes of the text from start to end with the given text
+// and reparses it
+func (p *IncrementalParser) Edit(start, end int, text string) (interface{}, error) {
+	if start < 0 || start > end || end > len(p.text) {
+		return nil, fmt.Errorf(`Invalid edit from %d to %d of a text of %d runes`, start, end, len(p.text))
+	}
+
+	inserted := []rune(text)
+	delta := len(inserted) - (end - start)
+
+	edited := append([]rune{}, p.text[:start]...)
+	edited = append(edited, inserted...)
+	p.text = append(edited, p.text[end:]...)
+
+	rules := map[incrementalKey]*incrementalEntry{}
+	for key, entry := range p.rules {
+		switch {
+		case entry.furthest < start:
+		case key.cursor >= end:
+			key.cursor += delta
+			entry.end += delta
+			entry.furthest += delta
+		default:
+			continue
+		}
+
+		rules[key] = entry
+	}
+	p.rules = rules
+
+	return p.parse()
+}
+
+func (p *IncrementalParser) parse() (interface{}, error) {
+	p.reused = 0
+
+	session := newParseSession(p.opts)
+	session.incremental = p
+
+	result, _, err := parseRunes(p.parser, strings.NewReader(string(p.text)), session)
+	return result, err
+}
+
+// incrementalContext returns the key of a rule at a state, or false if the state can't be cached
+func incrementalContext(rule Parser, s *RuneScanner) (incrementalKey, bool) {
+	if s.user != nil && !reflect.TypeOf(s.user).Comparable() {
+		return incrementalKey{}, false
+	}
+
+	widths := []string{}
+	for l := s.indents; l != nil; l = l.parent {
+		widths = append(widths, strconv.Itoa(l.width))
+	}
+
+	return incrementalKey{rule, s.cursor, strings.Join(widths, " "), s.verbatim, s.user}, true
+}
+
+// apply applies a combinator, reusing the previous results of Named rules
+func (p *IncrementalParser) apply(c *combinator, state ParserState, fn FuncParser) (*ParserResult, error) {
+	s, ok := state.(*RuneScanner)
+	if !ok || c.desc.Kind != KindNamed {
+		return fn(state)
+	}
+
+	key, ok := incrementalContext(c, s)
+	if !ok {
+		return fn(state)
+	}
+
+	in := s.input
+	if entry, ok := p.rules[key]; ok {
+		p.reused++
+
+		if entry.furthest > in.furthest {
+			in.furthest = entry.furthest
+		}
+		return Success(s.at(entry.end), entry.result)
+	}
+
+	outer := in.furthest
+	in.furthest = s.cursor
+
+	pr, err := fn(state)
+
+	furthest := in.furthest
+	if outer > in.furthest {
+		in.furthest = outer
+	}
+
+	if err != nil {
+		return pr, err
+	}
+
+	// only rules ending with the context they started with can be reused at another position
+	end, ok := pr.Remaining.(*RuneScanner)
+	if !ok {
+		return pr, err
+	}
+	if endKey, ok := incrementalContext(c, end); !ok || endKey.indents != key.indents || endKey.verbatim != key.verbatim || endKey.user != key.user {
+		return pr, err
+	}
+
+	// parsers can skip runes without examining them
+	if furthest < end.cursor-1 {
+		furthest = end.cursor - 1
+	}
+
+	p.rules[key] = &incrementalEntry{result: pr.Result, end: end.cursor, furthest: furthest}
+	return pr, err
+}
@@ -0,0 +1,75 @@
+package combinators
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertIncrementalAgrees applies random edits to a text and checks that
+// each incremental parse equals a full reparse
+func assertIncrementalAgrees(t *testing.T, parser Parser, text string, alphabet []string, edits int) {
+	random := rand.New(rand.NewSource(1))
+	p := NewIncrementalParser(parser)
+	p.Parse(text)
+
+	reused := 0
+	for i := 0; i < edits; i++ {
+		runes := []rune(p.Text())
+		start := random.Intn(len(runes) + 1)
+		end := start + random.Intn(len(runes)-start+1)%3
+		inserted := alphabet[random.Intn(len(alphabet))]
+
+		actual, actualErr := p.Edit(start, end, inserted)
+		expected, expectedErr := ParseRuneReader(parser, strings.NewReader(p.Text()))
+		reused += p.Reused()
+
+		assert.Equal(t, expected, actual, "text %q", p.Text())
+		if expectedErr == nil {
+			assert.Nil(t, actualErr, "text %q", p.Text())
+		} else {
+			assert.EqualError(t, actualErr, expectedErr.Error(), "text %q", p.Text())
+		}
+	}
+
+	assert.NotZero(t, reused)
+}
+
+func TestIncremental(t *testing.T) {
+	assertIncrementalAgrees(t, SeqOf(expr, EOF), "(1+2)*(3-4)/5+((6))*78", []string{"", "1", "+", "(", ")", "9*"}, 300)
+
+	{
+		// P gets its result from the cache of Memoize, it must still depend on the "b" examined by x
+		x := Memoize(SeqOf(Expect('a'), Lookahead(Expect('b'))))
+		top := SeqOf(AnyOf(Named("Q", SeqOf(x, Expect('z'))), Named("P", x)), ZeroOrMore(Any), EOF)
+
+		p := NewIncrementalParser(top)
+		_, err := p.Parse("ab")
+		assert.Nil(t, err)
+
+		_, err = p.Edit(1, 2, "c")
+		_, expectedErr := ParseRuneReader(top, strings.NewReader("ac"))
+		assert.NotNil(t, expectedErr)
+		assert.EqualError(t, err, expectedErr.Error())
+	}
+
+	{
+		p := NewIncrementalParser(SeqOf(expr, EOF))
+
+		r, err := p.Parse("12+34*56")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, p.Reused())
+
+		// the product on the right is reused, shifted by the edit
+		r2, err := p.Edit(0, 2, "7")
+		assert.Nil(t, err)
+		assert.Equal(t, "7+34*56", p.Text())
+		assert.Equal(t, 1, p.Reused())
+		assert.Equal(t, r.([]interface{})[0].([]interface{})[1], r2.([]interface{})[0].([]interface{})[1])
+
+		_, err = p.Edit(3, 9, "")
+		assert.EqualError(t, err, "Invalid edit from 3 to 9 of a text of 7 runes")
+	}
+}
@@ -0,0 +1,177 @@
+package combinators
+
+import (
+	"fmt"
+)
+
+// indentLevel is an immutable stack of indentation widths, states share it
+// so backtracking automatically restores the previous levels
+type indentLevel struct {
+	width  int
+	parent *indentLevel
+}
+
+func (l *indentLevel) current() int {
+	if l == nil {
+		return 0
+	}
+
+	return l.width
+}
+
+// indentState is implemented by parser states that track indentation levels
+type indentState interface {
+	indentation() *indentLevel
+	withIndentation(levels *indentLevel) ParserState
+}
+
+func (s *RuneScanner) indentation() *indentLevel {
+	return s.indents
+}
+
+func (s *RuneScanner) withIndentation(levels *indentLevel) ParserState {
+	next := *s
+	next.indents = levels
+	return &next
+}
+
+// Layout configures the indentation sensitive combinators
+type Layout struct {
+	// TabWidth is the number of columns between tab stops, when zero tabs count as 4 columns
+	TabWidth int
+}
+
+// DefaultLayout is the Layout used by the package level indentation combinators
+var DefaultLayout = &Layout{TabWidth: 4}
+
+// measure reads the spaces and tabs at the current position and returns their width in columns
+func (l *Layout) measure(state ParserState) (int, ParserState) {
+	tabWidth := l.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = 4
+	}
+
+	width := 0
+	currentState := state
+
+	for {
+		switch currentState.CurrentRune() {
+		case ' ':
+			width++
+		case '\t':
+			width += tabWidth - width%tabWidth
+		default:
+			return width, currentState
+		}
+
+		currentState = currentState.Remaining()
+	}
+}
+
+func (l *Layout) levels(state ParserState) (indentState, error) {
+	is, ok := state.(indentState)
+	if !ok {
+		return nil, fmt.Errorf(`Indentation is not supported by %T`, state)
+	}
+
+	return is, nil
+}
+
+// Indented runs a parser with a new indentation level equal to the
+// indentation of the current line, that must be greater than the enclosing
+// one. The indentation itself is not consumed, see SameIndent.
+func (l *Layout) Indented(parser Parser) Parser {
+	return newCombinator(Description{Kind: KindIndented, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		is, err := l.levels(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		outer := is.indentation()
+		width, _ := l.measure(state)
+
+		if width <= outer.current() {
+			return Fail(state, ErrorAt(state, fmt.Errorf(`Expected indentation greater than %d, found %d`, outer.current(), width)))
+		}
+
+		pr, err := parser.Apply(is.withIndentation(&indentLevel{width, outer}))
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return Success(pr.Remaining.(indentState).withIndentation(outer), pr.Result)
+	})
+}
+
+// SameIndent consumes the indentation of the current line, that must be
+// equal to the current indentation level, and then runs the given parser
+func (l *Layout) SameIndent(parser Parser) Parser {
+	return newCombinator(Description{Kind: KindSameIndent, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		is, err := l.levels(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		width, afterIndent := l.measure(state)
+		if expected := is.indentation().current(); width != expected {
+			return Fail(state, ErrorAt(state, fmt.Errorf(`Expected indentation of %d, found %d`, expected, width)))
+		}
+
+		pr, err := parser.Apply(afterIndent)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return pr, nil
+	})
+}
+
+// IndentGreater consumes the indentation of the current line, that must be
+// greater than the current indentation level, and then runs the given parser
+// without changing the level. This is useful for continuation lines.
+func (l *Layout) IndentGreater(parser Parser) Parser {
+	return newCombinator(Description{Kind: KindIndentGreater, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		is, err := l.levels(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		width, afterIndent := l.measure(state)
+		if current := is.indentation().current(); width <= current {
+			return Fail(state, ErrorAt(state, fmt.Errorf(`Expected indentation greater than %d, found %d`, current, width)))
+		}
+
+		pr, err := parser.Apply(afterIndent)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return pr, nil
+	})
+}
+
+// Block matches one or more lines (or items) at the same indentation,
+// greater than the enclosing one, like a Python block or a YAML mapping
+func (l *Layout) Block(parser Parser) Parser {
+	return l.Indented(OneOrMore(l.SameIndent(parser)))
+}
+
+// Indented - see Layout.Indented
+func Indented(parser Parser) Parser {
+	return DefaultLayout.Indented(parser)
+}
+
+// SameIndent - see Layout.SameIndent
+func SameIndent(parser Parser) Parser {
+	return DefaultLayout.SameIndent(parser)
+}
+
+// IndentGreater - see Layout.IndentGreater
+func IndentGreater(parser Parser) Parser {
+	return DefaultLayout.IndentGreater(parser)
+}
+
+// Block - see Layout.Block
+func Block(parser Parser) Parser {
+	return DefaultLayout.Block(parser)
+}
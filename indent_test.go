@@ -0,0 +1,65 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tree is a YAML like grammar of nested keys
+var tree Parser
+
+func init() {
+	key := StringifyResult(OneOrMore(Letter))
+	lineEnd := AnyOf(Newline, EOF)
+
+	tree = Block(
+		Transform(
+			SeqOf(
+				key,
+				SeqIgnore(lineEnd),
+				Optional(FuncParser(func(state ParserState) (*ParserResult, error) {
+					return tree.Apply(state)
+				})),
+			),
+			func(i interface{}) interface{} {
+				seq := i.([]interface{})
+				if seq[1] == nil {
+					return seq[0]
+				}
+				return map[string]interface{}{seq[0].(string): seq[1]}
+			},
+		),
+	)
+}
+
+func TestIndentation(t *testing.T) {
+	{
+		r, err := ParseRuneReader(tree, strings.NewReader(" a\n  b\n c\n\t d\n e\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"a": []interface{}{"b"}},
+			map[string]interface{}{"c": []interface{}{"d"}},
+			"e",
+		}, r)
+	}
+	{
+		layout := &Layout{TabWidth: 2}
+		parser := layout.Block(SeqOf(Letter, SeqIgnore(Newline)))
+		r, _ := ParseRuneReader(parser, strings.NewReader("\ta\n  b\n   c\n"))
+		assert.Equal(t, []interface{}{[]interface{}{"a"}, []interface{}{"b"}}, r)
+	}
+	{
+		_, err := ParseRuneReader(tree, strings.NewReader("a\n"))
+		assert.EqualError(t, err, `Expected indentation greater than 0, found 0 at 1:1`)
+	}
+	{
+		parser := SeqOf(SameIndent(Letter), SeqIgnore(Newline), IndentGreater(Letter))
+		r, _ := ParseRuneReader(parser, strings.NewReader("a\n   b"))
+		assert.Equal(t, []interface{}{"a", "b"}, r)
+
+		_, err := ParseRuneReader(parser, strings.NewReader("a\nb"))
+		assert.EqualError(t, err, `Expected indentation greater than 0, found 0 at 2:1`)
+	}
+}
@@ -0,0 +1,166 @@
+package combinators
+
+import (
+	"fmt"
+)
+
+// Skipper describes the insignificant text ("trivia") between tokens: whitespace
+// and comments. Token parsers wrapped with Lexeme consume the trivia that
+// follows them, so grammars don't need to mention it explicitly.
+type Skipper struct {
+	// Whitespace matches a single whitespace rune, when nil Space is used
+	Whitespace Parser
+	// LineComments are the prefixes of comments ending at the next newline, for example "//" or "#"
+	LineComments []string
+	// BlockCommentStart and BlockCommentEnd delimit block comments, for example "/*" and "*/"
+	BlockCommentStart string
+	BlockCommentEnd   string
+	// NestedComments allows block comments to contain other block comments
+	NestedComments bool
+}
+
+// Trivia creates a Parser that skips zero or more whitespace runes and comments,
+// the Result is the skipped text
+func (s *Skipper) Trivia() Parser {
+	whitespace := s.Whitespace
+	if whitespace == nil {
+		whitespace = Space
+	}
+
+	return newCombinator(Description{Kind: KindTrivia}, func(state ParserState) (*ParserResult, error) {
+		currentState := state
+
+		for currentState.CurrentRune() != 0 {
+			next, err := s.skipOne(whitespace, currentState)
+			if err != nil {
+				return Fail(currentState, err)
+			}
+			if next == nil {
+				break
+			}
+
+			currentState = next
+		}
+
+		return Success(currentState, ConsumedText(state, currentState))
+	})
+}
+
+// skipOne skips a single whitespace rune or comment, returns nil if the input
+// doesn't start with trivia
+func (s *Skipper) skipOne(whitespace Parser, state ParserState) (ParserState, error) {
+	if pr, err := whitespace.Apply(state); err == nil {
+		return pr.Remaining, nil
+	}
+
+	for _, prefix := range s.LineComments {
+		if next, ok := matchPrefix(state, prefix); ok {
+			for next.CurrentRune() != 0 && next.CurrentRune() != '\n' {
+				next = next.Remaining()
+			}
+
+			return next, nil
+		}
+	}
+
+	if s.BlockCommentStart != "" {
+		if next, ok := matchPrefix(state, s.BlockCommentStart); ok {
+			return s.skipBlockComment(state, next)
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *Skipper) skipBlockComment(start, state ParserState) (ParserState, error) {
+	depth := 1
+	currentState := state
+
+	for depth > 0 {
+		if currentState.CurrentRune() == 0 {
+			return nil, ErrorAt(start, fmt.Errorf(`Stream ended, expected "%s" closing comment`, s.BlockCommentEnd))
+		}
+
+		if next, ok := matchPrefix(currentState, s.BlockCommentEnd); ok {
+			depth--
+			currentState = next
+			continue
+		}
+
+		if s.NestedComments {
+			if next, ok := matchPrefix(currentState, s.BlockCommentStart); ok {
+				depth++
+				currentState = next
+				continue
+			}
+		}
+
+		currentState = currentState.Remaining()
+	}
+
+	return currentState, nil
+}
+
+// Lexeme wraps a token parser so that it also consumes the trivia following
+// it, unless used inside Verbatim
+func (s *Skipper) Lexeme(parser Parser) Parser {
+	trivia := s.Trivia()
+
+	return newCombinator(Description{Kind: KindLexeme, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		pr, err := parser.Apply(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		if v, ok := pr.Remaining.(verbatimState); ok && v.isVerbatim() {
+			return pr, nil
+		}
+
+		tr, err := trivia.Apply(pr.Remaining)
+		if err != nil {
+			return Fail(tr.Remaining, err)
+		}
+
+		return Success(tr.Remaining, pr.Result)
+	})
+}
+
+// Symbol is a Lexeme for the given string
+func (s *Skipper) Symbol(text string) Parser {
+	return s.Lexeme(ExpectString([]rune(text)))
+}
+
+// verbatimState is implemented by parser states that can disable trivia skipping
+type verbatimState interface {
+	isVerbatim() bool
+	withVerbatim(verbatim bool) ParserState
+}
+
+func (s *RuneScanner) isVerbatim() bool {
+	return s.verbatim
+}
+
+func (s *RuneScanner) withVerbatim(verbatim bool) ParserState {
+	next := *s
+	next.verbatim = verbatim
+	return &next
+}
+
+// Verbatim runs the given parser with trivia skipping disabled, so lexemes
+// inside it don't consume whitespace and comments. This is useful for
+// whitespace sensitive regions like string interpolations.
+func Verbatim(parser Parser) Parser {
+	return newCombinator(Description{Kind: KindVerbatim, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		v, ok := state.(verbatimState)
+		if !ok {
+			return parser.Apply(state)
+		}
+
+		pr, err := parser.Apply(v.withVerbatim(true))
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return Success(pr.Remaining.(verbatimState).withVerbatim(v.isVerbatim()), pr.Result)
+	})
+}
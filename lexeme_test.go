@@ -0,0 +1,62 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexeme(t *testing.T) {
+	skipper := &Skipper{
+		LineComments:      []string{"//", "#"},
+		BlockCommentStart: "/*",
+		BlockCommentEnd:   "*/",
+		NestedComments:    true,
+	}
+
+	number := skipper.Lexeme(StringifyResult(OneOrMore(Digit)))
+	sum := SeqOf(
+		SeqIgnore(skipper.Trivia()),
+		number,
+		SeqIgnore(skipper.Symbol("+")),
+		number,
+		EOF,
+	)
+
+	{
+		r, err := ParseRuneReader(sum, strings.NewReader("1+2"))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"1", "2", "\x00"}, r)
+	}
+	{
+		r, err := ParseRuneReader(sum, strings.NewReader(" // leading\n 12 /* a /* nested */ comment */ + # line\n\t34  "))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"12", "34", "\x00"}, r)
+	}
+	{
+		_, err := ParseRuneReader(sum, strings.NewReader("1 /* a /* b */ + 2"))
+		assert.EqualError(t, err, `Stream ended, expected "*/" closing comment at 1:3`)
+	}
+	{
+		r, _ := ParseRuneReader(skipper.Trivia(), strings.NewReader(" /* x */ // y\nz"))
+		assert.Equal(t, " /* x */ // y\n", r)
+	}
+}
+
+func TestVerbatim(t *testing.T) {
+	skipper := &Skipper{Whitespace: InlineSpace}
+
+	word := skipper.Lexeme(StringifyResult(OneOrMore(Letter)))
+	words := OneOrMore(word)
+
+	{
+		r, _ := ParseRuneReader(SeqOf(words, Expect('!')), strings.NewReader("hello  big world!"))
+		assert.Equal(t, []interface{}{[]interface{}{"hello", "big", "world"}, "!"}, r)
+	}
+	{
+		parser := SeqOf(Verbatim(words), InlineSpace, word)
+		r, _ := ParseRuneReader(parser, strings.NewReader("hello world  !"))
+		assert.Equal(t, []interface{}{[]interface{}{"hello"}, " ", "world"}, r)
+	}
+}
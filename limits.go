@@ -0,0 +1,86 @@
+package combinators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is the error, wrapped in a ParseError, of the parses that
+// exceed one of their Limits
+var ErrLimitExceeded = errors.New(`Limit exceeded`)
+
+// Limits bound the resources used by a single parse of untrusted input, the
+// zero value of each field means no limit
+type Limits struct {
+	// MaxSteps is the maximum number of combinators applied, compiled
+	// Programs count their calls and backtrack points instead
+	MaxSteps int
+	// MaxDepth is the maximum nesting of the combinators being applied, or
+	// of the calls and backtrack points of compiled Programs
+	MaxDepth int
+	// MaxInputSize is the maximum size of the input in bytes
+	MaxInputSize int
+	// MaxMemoBytes is the maximum estimated memory of the results cached by Memoize
+	MaxMemoBytes int
+}
+
+// WithLimits bounds the resources used by the parse, when a limit is
+// exceeded the whole parse fails with an error wrapping ErrLimitExceeded
+func WithLimits(limits Limits) ParseOption {
+	return func(session *parseSession) {
+		session.limits = limits
+	}
+}
+
+// WithContext stops the parse when the context is done, the parse fails
+// with an error wrapping the error of the context, like context.Canceled
+func WithContext(ctx context.Context) ParseOption {
+	return func(session *parseSession) {
+		session.ctx = ctx
+	}
+}
+
+// contextCheckInterval is the number of steps between two checks of the context
+const contextCheckInterval = 256
+
+// check counts a step at the given depth, it returns the reason to abort the parse if any
+func (s *parseSession) check(depth int) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.steps++
+	if max := s.limits.MaxSteps; max > 0 && s.steps > max {
+		return fmt.Errorf(`%w: more than %d steps`, ErrLimitExceeded, max)
+	}
+	if max := s.limits.MaxDepth; max > 0 && depth > max {
+		return fmt.Errorf(`%w: more than %d nested parsers`, ErrLimitExceeded, max)
+	}
+	if s.ctx != nil && s.steps%contextCheckInterval == 1 {
+		return s.ctx.Err()
+	}
+
+	return nil
+}
+
+// abort makes the whole parse fail with the given error, the first one is kept
+func (s *parseSession) abort(state ParserState, err error) error {
+	if s.err == nil {
+		s.err = ErrorAt(state, err)
+	}
+
+	return s.err
+}
+
+// result returns the result of a parse, unless it was aborted
+func (s *parseSession) result(pr *ParserResult, err error) (interface{}, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pr.Result, nil
+}
@@ -0,0 +1,106 @@
+package combinators
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nested parses balanced parentheses around an "x", trying the first
+// alternative reparses the inner parentheses so it takes exponential time
+func nested(memoize bool) Parser {
+	var p Parser
+	ref := Lazy(func() Parser {
+		return p
+	})
+	if memoize {
+		ref = Memoize(ref)
+	}
+
+	p = AnyOf(
+		StringifyResult(SeqOf(Expect('('), ref, Expect(')'), Expect('!'))),
+		StringifyResult(SeqOf(Expect('('), ref, Expect(')'))),
+		Expect('x'),
+	)
+	return p
+}
+
+func TestLimits(t *testing.T) {
+	input := strings.Repeat("(", 20) + "x" + strings.Repeat(")", 20)
+
+	{
+		_, err := ParseRuneReader(nested(false), strings.NewReader(input), WithLimits(Limits{MaxSteps: 10000}))
+		assert.True(t, errors.Is(err, ErrLimitExceeded))
+		assert.Contains(t, err.Error(), "Limit exceeded: more than 10000 steps at 1:")
+	}
+	{
+		// backtracking can't recover from an exceeded limit
+		_, err := ParseRuneReader(Optional(nested(false)), strings.NewReader(input), WithLimits(Limits{MaxSteps: 10000}))
+		assert.True(t, errors.Is(err, ErrLimitExceeded))
+	}
+	{
+		_, err := ParseRuneReader(Compile(nested(false)), strings.NewReader(input), WithLimits(Limits{MaxSteps: 10000}))
+		assert.True(t, errors.Is(err, ErrLimitExceeded))
+	}
+	{
+		r, err := ParseRuneReader(nested(true), strings.NewReader(input), WithLimits(Limits{MaxSteps: 10000}))
+		assert.Nil(t, err)
+		assert.Equal(t, input, r)
+	}
+	{
+		_, err := ParseRuneReader(nested(true), strings.NewReader(input), WithLimits(Limits{MaxMemoBytes: 10 * memoEntrySize}))
+		assert.True(t, errors.Is(err, ErrLimitExceeded))
+		assert.Contains(t, err.Error(), "Limit exceeded: more than ")
+	}
+	{
+		// the parse stops when the cache is full, instead of going on without it
+		calls := 0
+		var p Parser
+		ref := Memoize(Lazy(func() Parser {
+			return p
+		}))
+		count := FuncParser(func(state ParserState) (*ParserResult, error) {
+			calls++
+			return Success(state, nil)
+		})
+		p = AnyOf(SeqOf(count, Expect('('), ref, Expect(')'), Expect('!')), SeqOf(Expect('('), ref, Expect(')')), Expect('x'))
+
+		_, err := ParseRuneReader(p, strings.NewReader(input), WithLimits(Limits{MaxMemoBytes: 10 * memoEntrySize}))
+		assert.True(t, errors.Is(err, ErrLimitExceeded))
+		assert.LessOrEqual(t, calls, 21)
+	}
+	{
+		_, err := ParseRuneReader(nested(true), strings.NewReader(input), WithLimits(Limits{MaxDepth: 50}))
+		assert.EqualError(t, err, "Limit exceeded: more than 50 nested parsers at 1:13")
+	}
+	{
+		_, err := ParseRuneReader(ZeroOrMore(Any), strings.NewReader("abcdèf"), WithLimits(Limits{MaxInputSize: 6}))
+		assert.EqualError(t, err, "Limit exceeded: more than 6 bytes of input at 1:6")
+
+		r, err := ParseRuneReader(StringifyResult(ZeroOrMore(Any)), strings.NewReader("abcdè"), WithLimits(Limits{MaxInputSize: 6}))
+		assert.Nil(t, err)
+		assert.Equal(t, "abcdè", r)
+	}
+	{
+		_, err := testTokenizer.Parse(ExpectToken("ident"), strings.NewReader("abc def"), WithLimits(Limits{MaxInputSize: 6}))
+		assert.EqualError(t, err, "Limit exceeded: more than 6 bytes of input")
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ParseRuneReader(nested(false), strings.NewReader("(x)"), WithContext(ctx))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	_, err = ParseRuneReader(Compile(nested(false)), strings.NewReader("(x)"), WithContext(ctx))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	r, err := ParseRuneReader(nested(false), strings.NewReader("(x)"), WithContext(context.Background()))
+	assert.Nil(t, err)
+	assert.Equal(t, "(x)", r)
+}
@@ -0,0 +1,430 @@
+// Package lsp serves the Language Server Protocol for the languages defined
+// with a Parser: it publishes the parse errors as diagnostics, the Named
+// rules as the outline of the documents and the spans of Labels, or of chosen
+// rules and tokens, as semantic tokens for highlighting.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// Hooks customize a Server, all of them are optional
+type Hooks struct {
+	// Name identifies the server and is the source of its diagnostics
+	Name string
+	// ParseOptions are the options of every parse
+	ParseOptions []c.ParseOption
+	// Check returns more errors of a successfully parsed document, like the
+	// ones of a semantic analysis of its result. ParseErrors are located
+	// where they happened, errors with an Unwrap() []error method, like the
+	// ones of errors.Join, give one diagnostic for each of their errors.
+	Check func(result interface{}) error
+	// Symbols are the rules shown in the outline of the documents with their
+	// kind, when nil all the Named rules are shown as objects
+	Symbols map[string]SymbolKind
+	// TokenTypes are the semantic token types, like "keyword" or "comment",
+	// of the spans of the Labels of the grammar by their label, like
+	// Label(ident, "variable"). The rules and tokens outside Labels fall back
+	// to the token type of their CSTNode Kind. Nested spans take precedence
+	// over the enclosing ones.
+	TokenTypes map[string]string
+}
+
+// Server is a language server for the documents of a single language, it
+// keeps the open documents and reparses them on each change
+type Server struct {
+	parser c.Parser
+	hooks  Hooks
+	legend []string
+
+	conn        *conn
+	documents   map[string]*document
+	initialized bool
+}
+
+// document is an open document and its last parse
+type document struct {
+	text  []rune
+	lines []int
+	root  *c.CSTNode
+}
+
+// NewServer creates a language server for the documents parsed by the given parser
+func NewServer(parser c.Parser, hooks Hooks) *Server {
+	if hooks.Name == "" {
+		hooks.Name = "parser-combinators"
+	}
+
+	legend := []string{}
+	seen := map[string]bool{}
+	for _, tokenType := range hooks.TokenTypes {
+		if !seen[tokenType] {
+			seen[tokenType] = true
+			legend = append(legend, tokenType)
+		}
+	}
+	sort.Strings(legend)
+
+	return &Server{parser: parser, hooks: hooks, legend: legend, documents: map[string]*document{}}
+}
+
+// Serve reads the requests of a client from r and writes the responses to w,
+// usually the standard input and output, until the client sends the exit
+// notification or closes r
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	for {
+		body, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		msg := &message{}
+		if err := json.Unmarshal(body, msg); err != nil {
+			if err := s.conn.write(errorResponse{"2.0", nil, &ResponseError{CodeParseError, err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers a request, notifications have no ID and get no answer
+func (s *Server) handle(msg *message) error {
+	result, err := s.call(msg.Method, msg.Params)
+	if msg.ID == nil {
+		return nil
+	}
+
+	if err != nil {
+		re, ok := err.(*ResponseError)
+		if !ok {
+			re = &ResponseError{CodeInvalidParams, err.Error()}
+		}
+
+		return s.conn.write(errorResponse{"2.0", msg.ID, re})
+	}
+
+	return s.conn.write(response{"2.0", msg.ID, result})
+}
+
+func (s *Server) call(method string, params json.RawMessage) (interface{}, error) {
+	if !s.initialized && method != "initialize" {
+		return nil, &ResponseError{CodeServerNotInitialized, "Server not initialized"}
+	}
+
+	switch method {
+	case "initialize":
+		s.initialized = true
+
+		result := initializeResult{Capabilities: serverCapabilities{
+			TextDocumentSync:       textDocumentSyncFull,
+			DocumentSymbolProvider: true,
+		}}
+		result.ServerInfo.Name = s.hooks.Name
+		if len(s.legend) > 0 {
+			result.Capabilities.SemanticTokensProvider = map[string]interface{}{
+				"legend": semanticTokensLegend{s.legend, []string{}},
+				"full":   true,
+			}
+		}
+
+		return result, nil
+
+	case "initialized", "shutdown":
+		return nil, nil
+
+	case "textDocument/didOpen":
+		p := didOpenParams{}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+
+		return nil, s.update(p.TextDocument.URI, p.TextDocument.Text)
+
+	case "textDocument/didChange":
+		p := didChangeParams{}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+
+		return nil, s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+
+	case "textDocument/didClose":
+		p := documentParams{}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+
+		delete(s.documents, p.TextDocument.URI)
+		return nil, s.conn.write(notification{"2.0", "textDocument/publishDiagnostics", publishDiagnosticsParams{p.TextDocument.URI, []Diagnostic{}}})
+
+	case "textDocument/documentSymbol":
+		doc, err := s.document(params)
+		if err != nil {
+			return nil, err
+		}
+
+		return doc.symbols(doc.root, s.hooks.Symbols), nil
+
+	case "textDocument/semanticTokens/full":
+		doc, err := s.document(params)
+		if err != nil {
+			return nil, err
+		}
+
+		return doc.semanticTokens(s.hooks.TokenTypes, s.legend), nil
+	}
+
+	if strings.HasPrefix(method, "$/") {
+		return nil, nil
+	}
+
+	return nil, &ResponseError{CodeMethodNotFound, fmt.Sprintf("Method %q not found", method)}
+}
+
+func (s *Server) document(params json.RawMessage) (*document, error) {
+	p := documentParams{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil, &ResponseError{CodeInvalidParams, fmt.Sprintf("Document %q is not open", p.TextDocument.URI)}
+	}
+
+	return doc, nil
+}
+
+// update parses the new text of a document and publishes its diagnostics
+func (s *Server) update(uri, text string) error {
+	doc := &document{text: []rune(text), lines: []int{0}}
+	for i, r := range doc.text {
+		if r == '\n' {
+			doc.lines = append(doc.lines, i+1)
+		}
+	}
+	s.documents[uri] = doc
+
+	opts := append([]c.ParseOption{c.WithCSTLabels()}, s.hooks.ParseOptions...)
+	result, root, err := c.ParseCST(s.parser, strings.NewReader(text), opts...)
+	if err == nil {
+		doc.root = root
+
+		if root.End < len(doc.text) {
+			err = &c.ParseError{Offset: root.End, Err: fmt.Errorf(`Unexpected text after the end of the document`)}
+		} else if s.hooks.Check != nil {
+			err = s.hooks.Check(result)
+		}
+	}
+
+	diagnostics := []Diagnostic{}
+	for _, e := range errorList(err) {
+		diagnostics = append(diagnostics, doc.diagnostic(e, s.hooks.Name))
+	}
+
+	return s.conn.write(notification{"2.0", "textDocument/publishDiagnostics", publishDiagnosticsParams{uri, diagnostics}})
+}
+
+// errorList returns the errors joined in an error
+func errorList(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := []error{}
+		for _, e := range multi.Unwrap() {
+			errs = append(errs, errorList(e)...)
+		}
+		return errs
+	}
+
+	return []error{err}
+}
+
+// position converts an offset in runes to a protocol position
+func (doc *document) position(offset int) Position {
+	if offset > len(doc.text) {
+		offset = len(doc.text)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	line := sort.Search(len(doc.lines), func(i int) bool { return doc.lines[i] > offset }) - 1
+
+	character := 0
+	for _, r := range doc.text[doc.lines[line]:offset] {
+		character += utf16Len(r)
+	}
+
+	return Position{line, character}
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
+
+func (doc *document) diagnostic(err error, source string) Diagnostic {
+	offset, message := 0, err.Error()
+
+	var pe *c.ParseError
+	if errors.As(err, &pe) {
+		if pe.Offset > 0 {
+			offset = pe.Offset
+		}
+
+		message = fmt.Sprint(pe.Err)
+		if len(pe.Rules) > 0 {
+			message += fmt.Sprintf(" (in %s)", strings.Join(pe.Rules, " > "))
+		}
+	}
+
+	// the range covers the rune where the error happened, if any
+	end := offset
+	if offset < len(doc.text) && doc.text[offset] != '\n' {
+		end++
+	}
+
+	return Diagnostic{
+		Range:    Range{doc.position(offset), doc.position(end)},
+		Severity: SeverityError,
+		Source:   source,
+		Message:  message,
+	}
+}
+
+// span returns the offsets of the text of a node without the trivia around it
+func span(node *c.CSTNode) (int, int) {
+	tokens := node.Tokens()
+	if len(tokens) == 0 {
+		return node.Start, node.Start
+	}
+
+	return tokens[0].Start, tokens[len(tokens)-1].End
+}
+
+// symbols returns the outline of the rules inside a node
+func (doc *document) symbols(node *c.CSTNode, kinds map[string]SymbolKind) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	if node == nil {
+		return symbols
+	}
+
+	for _, child := range node.Children {
+		if child.Token {
+			continue
+		}
+
+		children := doc.symbols(child, kinds)
+		if child.Label {
+			symbols = append(symbols, children...)
+			continue
+		}
+
+		kind, ok := SymbolKindObject, kinds == nil
+		if kinds != nil {
+			kind, ok = kinds[child.Kind]
+		}
+		if !ok {
+			symbols = append(symbols, children...)
+			continue
+		}
+
+		name := strings.TrimSpace(strings.SplitN(strings.TrimSpace(child.Source()), "\n", 2)[0])
+		if name == "" {
+			name = child.Kind
+		}
+
+		start, end := span(child)
+		r := Range{doc.position(start), doc.position(end)}
+		symbols = append(symbols, DocumentSymbol{name, child.Kind, kind, r, r, children})
+	}
+
+	return symbols
+}
+
+// semanticTokens encodes the spans of the nodes with a token type, splitting them at newlines
+func (doc *document) semanticTokens(types map[string]string, legend []string) *SemanticTokens {
+	tokens := &SemanticTokens{Data: []int{}}
+	if doc.root == nil {
+		return tokens
+	}
+
+	indices := map[string]int{}
+	for i, tokenType := range legend {
+		indices[tokenType] = i
+	}
+
+	// painted holds the index of the token type of each rune plus one
+	painted := make([]int, len(doc.text))
+
+	// paint paints the spans of the Labels or of the other nodes
+	var paint func(node *c.CSTNode, labels bool)
+	paint = func(node *c.CSTNode, labels bool) {
+		if tokenType, ok := types[node.Kind]; ok && node != doc.root && node.Label == labels {
+			start, end := span(node)
+			for i := start; i < end; i++ {
+				painted[i] = indices[tokenType] + 1
+			}
+		}
+
+		for _, child := range node.Children {
+			paint(child, labels)
+		}
+	}
+	paint(doc.root, false)
+	paint(doc.root, true)
+
+	prev := Position{}
+	for i := 0; i < len(painted); {
+		if painted[i] == 0 || doc.text[i] == '\n' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(painted) && painted[i] == painted[start] && doc.text[i] != '\n' {
+			i++
+		}
+
+		pos, end := doc.position(start), doc.position(i)
+		deltaStart := pos.Character
+		if pos.Line == prev.Line {
+			deltaStart -= prev.Character
+		}
+
+		tokens.Data = append(tokens.Data, pos.Line-prev.Line, deltaStart, end.Character-pos.Character, painted[start]-1, 0)
+		prev = pos
+	}
+
+	return tokens
+}
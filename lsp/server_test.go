@@ -0,0 +1,195 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/stretchr/testify/assert"
+)
+
+var skipper = &c.Skipper{LineComments: []string{"#"}}
+
+var program = c.SeqOf(
+	c.SeqIgnore(skipper.Trivia()),
+	c.RepeatUntil(
+		c.Named("Assign", c.SeqOf(
+			c.Named("Name", skipper.Lexeme(c.StringifyResult(c.OneOrMore(c.Letter)))),
+			c.SeqIgnore(c.Label(skipper.Symbol("="), "assignment")),
+			c.Named("Number", skipper.Lexeme(c.StringifyResult(c.OneOrMore(c.Digit)))),
+		)),
+		c.EOF,
+	),
+)
+
+// client drives a Server through in-process pipes
+type client struct {
+	t    *testing.T
+	conn *conn
+	done chan error
+}
+
+func newClient(t *testing.T, hooks Hooks) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	cl := &client{t, newConn(clientIn, clientOut), make(chan error, 1)}
+	go func() {
+		cl.done <- NewServer(program, hooks).Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	return cl
+}
+
+// send sends a request, or a notification when id is 0
+func (cl *client) send(id int, method string, params interface{}) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if id != 0 {
+		msg["id"] = id
+	}
+
+	assert.Nil(cl.t, cl.conn.write(msg))
+}
+
+// receive returns the next message, decoding its result or params into v
+func (cl *client) receive(v interface{}) *message {
+	body, err := cl.conn.read()
+	assert.Nil(cl.t, err)
+
+	msg := &message{}
+	assert.Nil(cl.t, json.Unmarshal(body, msg))
+
+	data := msg.Result
+	if msg.Method != "" {
+		data = msg.Params
+	}
+	if v != nil {
+		assert.Nil(cl.t, json.Unmarshal(data, v))
+	}
+
+	return msg
+}
+
+func documentParam(uri string) map[string]interface{} {
+	return map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}}
+}
+
+func TestServer(t *testing.T) {
+	cl := newClient(t, Hooks{
+		Name:    "assignments",
+		Symbols: map[string]SymbolKind{"Assign": SymbolKindVariable},
+		// the Label of "=" takes precedence over the type of its token
+		TokenTypes: map[string]string{"Name": "variable", "Number": "number", "assignment": "operator", `ExpectString("=")`: "keyword"},
+	})
+
+	cl.send(1, "textDocument/documentSymbol", documentParam("file:///a"))
+	msg := cl.receive(nil)
+	assert.Equal(t, CodeServerNotInitialized, msg.Error.Code)
+
+	result := map[string]interface{}{}
+	cl.send(2, "initialize", map[string]interface{}{})
+	msg = cl.receive(&result)
+	assert.Equal(t, `2`, string(*msg.ID))
+	assert.Equal(t, map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":       1.0,
+			"documentSymbolProvider": true,
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": map[string]interface{}{"tokenTypes": []interface{}{"keyword", "number", "operator", "variable"}, "tokenModifiers": []interface{}{}},
+				"full":   true,
+			},
+		},
+		"serverInfo": map[string]interface{}{"name": "assignments"},
+	}, result)
+	cl.send(0, "initialized", map[string]interface{}{})
+
+	// parse errors
+	diagnostics := publishDiagnosticsParams{}
+	cl.send(0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///a", "languageId": "assignments", "version": 1, "text": "a = 1\n# 😀\nbc = x\n"},
+	})
+	msg = cl.receive(&diagnostics)
+	assert.Equal(t, "textDocument/publishDiagnostics", msg.Method)
+	assert.Equal(t, publishDiagnosticsParams{"file:///a", []Diagnostic{{
+		Range:    Range{Position{2, 5}, Position{2, 6}},
+		Severity: SeverityError,
+		Source:   "assignments",
+		Message:  `Expected "[digit]" (in Assign > Number)`,
+	}}}, diagnostics)
+
+	cl.send(0, "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": "file:///a", "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{"text": "a = 1\n# 😀\nbc = 23 # 😀 \n"}},
+	})
+	cl.receive(&diagnostics)
+	assert.Equal(t, []Diagnostic{}, diagnostics.Diagnostics)
+
+	// outline
+	symbols := []DocumentSymbol{}
+	cl.send(3, "textDocument/documentSymbol", documentParam("file:///a"))
+	cl.receive(&symbols)
+	assert.Equal(t, []DocumentSymbol{
+		{"a = 1", "Assign", SymbolKindVariable, Range{Position{0, 0}, Position{0, 5}}, Range{Position{0, 0}, Position{0, 5}}, nil},
+		{"bc = 23 # 😀", "Assign", SymbolKindVariable, Range{Position{2, 0}, Position{2, 7}}, Range{Position{2, 0}, Position{2, 7}}, nil},
+	}, symbols)
+
+	// semantic tokens are relative to the previous one
+	tokens := SemanticTokens{}
+	cl.send(4, "textDocument/semanticTokens/full", documentParam("file:///a"))
+	cl.receive(&tokens)
+	assert.Equal(t, []int{
+		0, 0, 1, 3, 0,
+		0, 2, 1, 2, 0,
+		0, 2, 1, 1, 0,
+		2, 0, 2, 3, 0,
+		0, 3, 1, 2, 0,
+		0, 2, 2, 1, 0,
+	}, tokens.Data)
+
+	cl.send(5, "textDocument/hover", documentParam("file:///a"))
+	msg = cl.receive(nil)
+	assert.Equal(t, &ResponseError{CodeMethodNotFound, `Method "textDocument/hover" not found`}, msg.Error)
+
+	cl.send(0, "textDocument/didClose", documentParam("file:///a"))
+	cl.receive(&diagnostics)
+	assert.Equal(t, publishDiagnosticsParams{"file:///a", []Diagnostic{}}, diagnostics)
+
+	cl.send(6, "textDocument/documentSymbol", documentParam("file:///a"))
+	msg = cl.receive(nil)
+	assert.Equal(t, CodeInvalidParams, msg.Error.Code)
+
+	cl.send(7, "shutdown", nil)
+	msg = cl.receive(nil)
+	assert.Equal(t, "null", string(msg.Result))
+	cl.send(0, "exit", nil)
+	assert.Nil(t, <-cl.done)
+}
+
+func TestDiagnostics(t *testing.T) {
+	doc := &document{text: []rune("ab\n😀c"), lines: []int{0, 3}}
+
+	diagnostic := doc.diagnostic(&c.ParseError{Offset: 4, Err: io.ErrUnexpectedEOF, Rules: []string{"Line"}}, "test")
+	assert.Equal(t, Diagnostic{Range{Position{1, 2}, Position{1, 3}}, SeverityError, "test", "unexpected EOF (in Line)"}, diagnostic)
+
+	// errors at the end of a line or of the text are empty ranges
+	assert.Equal(t, Range{Position{0, 2}, Position{0, 2}}, doc.diagnostic(&c.ParseError{Offset: 2, Err: io.EOF}, "").Range)
+	assert.Equal(t, Range{Position{1, 3}, Position{1, 3}}, doc.diagnostic(&c.ParseError{Offset: 5, Err: io.EOF}, "").Range)
+
+	// errors without a position are at the beginning
+	assert.Equal(t, Diagnostic{Range{Position{0, 0}, Position{0, 1}}, SeverityError, "", "EOF"}, doc.diagnostic(io.EOF, ""))
+
+	assert.Len(t, errorList(joined{io.EOF, joined{io.ErrUnexpectedEOF, io.ErrClosedPipe}}), 3)
+}
+
+// joined is an error made of many errors
+type joined []error
+
+func (j joined) Error() string {
+	return "many errors"
+}
+
+func (j joined) Unwrap() []error {
+	return j
+}
@@ -0,0 +1,87 @@
+package combinators
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// memoKey identifies the application of a Memoize parser to a RuneScanner
+type memoKey struct {
+	parser   Parser
+	cursor   int
+	indents  *indentLevel
+	verbatim bool
+	user     interface{}
+}
+
+type memoEntry struct {
+	result *ParserResult
+	err    error
+	// furthest is the greatest offset examined by the parser, a cache hit
+	// examines it again for the rules of an IncrementalParser
+	furthest int
+}
+
+// memoEntrySize estimates the memory used by a cached result, without the result value itself
+const memoEntrySize = int(unsafe.Sizeof(memoKey{}) + unsafe.Sizeof(memoEntry{}) + unsafe.Sizeof(ParserResult{}) + unsafe.Sizeof(RuneScanner{}))
+
+// Memoize caches the results and errors of a parser at each position of a
+// parse, so backtracking grammars apply it at most once per position like a
+// packrat parser. The indentation levels, the trivia mode and the user state
+// are part of the position, user states of types that are not comparable
+// disable the cache. Cached results are shared and must not be mutated by
+// later transforms. Only RuneScanner states are cached, Compile ignores it.
+func Memoize(parser Parser) Parser {
+	var self Parser
+	self = newCombinator(Description{Kind: KindMemoize, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		s, ok := state.(*RuneScanner)
+		if !ok || s.input.session.cst != nil || s.user != nil && !reflect.TypeOf(s.user).Comparable() {
+			return parser.Apply(state)
+		}
+
+		session := s.input.session
+		key := memoKey{self, s.cursor, s.indents, s.verbatim, s.user}
+		in := s.input
+		if entry, ok := session.memo[key]; ok {
+			if entry.furthest > in.furthest {
+				in.furthest = entry.furthest
+			}
+			return entry.result, entry.err
+		}
+
+		outer := in.furthest
+		in.furthest = s.cursor
+
+		pr, err := parser.Apply(state)
+
+		furthest := in.furthest
+		if outer > in.furthest {
+			in.furthest = outer
+		}
+		if session.err != nil {
+			return pr, err
+		}
+
+		// parsers can skip runes without examining them
+		if err == nil {
+			if end, ok := pr.Remaining.(*RuneScanner); ok && furthest < end.cursor-1 {
+				furthest = end.cursor - 1
+			}
+		}
+
+		session.memoBytes += memoEntrySize
+		if max := session.limits.MaxMemoBytes; max > 0 && session.memoBytes > max {
+			return Fail(state, session.abort(state, fmt.Errorf(`%w: more than %d bytes of memoized results`, ErrLimitExceeded, max)))
+		}
+
+		if session.memo == nil {
+			session.memo = map[memoKey]memoEntry{}
+		}
+		session.memo[key] = memoEntry{pr, err, furthest}
+
+		return pr, err
+	})
+
+	return self
+}
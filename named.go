@@ -0,0 +1,73 @@
+package combinators
+
+import (
+	"fmt"
+)
+
+// Named gives a name to a grammar rule. The name identifies the rule in
+// traces and grammar exports, and when the rule fails it is added to the
+// Rules of the resulting ParseError so errors can tell in which rules they
+// happened, the same goes for the errors of recovered panics.
+func Named(name string, parser Parser) Parser {
+	return newCombinator(Description{Kind: KindNamed, Label: name, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		defer func() {
+			if r := recover(); r != nil {
+				p := located(state, r)
+				p.err = withRule(state, p.err, name)
+				panic(p)
+			}
+		}()
+
+		pr, err := parser.Apply(state)
+		if err != nil {
+			failed := state
+			if pr != nil && pr.Remaining != nil {
+				failed = pr.Remaining
+			}
+
+			return Fail(state, withRule(failed, err, name))
+		}
+
+		return pr, nil
+	})
+}
+
+// withRule returns a copy of the error as a ParseError with the given rule on top of its rule stack
+func withRule(state ParserState, err error, rule string) error {
+	pe, ok := err.(*ParseError)
+	if !ok {
+		pe = &ParseError{Offset: -1, Err: err}
+		if locator, ok := state.(Locator); ok {
+			pe.Offset, pe.locator = locator.Offset(), locator
+		}
+	}
+
+	located := *pe
+	located.Rules = append([]string{rule}, pe.Rules...)
+	return &located
+}
+
+// Label replaces the low level expectations of a parser in error messages
+// with a description, for example "Expected heading" instead of the error of
+// the first rune that didn't match. Errors located after the beginning of the
+// labeled parser are more informative and are kept as they are.
+func Label(parser Parser, label string) Parser {
+	return newCombinator(Description{Kind: KindLabel, Label: label, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		pr, err := parser.Apply(state)
+		if err == nil {
+			return pr, nil
+		}
+
+		if pe, ok := err.(*ParseError); ok {
+			if locator, ok := state.(Locator); ok && pe.Offset > locator.Offset() {
+				return Fail(state, err)
+			}
+		}
+
+		if state.CurrentRune() == 0 {
+			return Fail(state, ErrorAt(state, fmt.Errorf(`Stream ended, expected %s`, label)))
+		}
+
+		return Fail(state, ErrorAt(state, fmt.Errorf(`Expected %s`, label)))
+	})
+}
@@ -0,0 +1,62 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamed(t *testing.T) {
+	number := Named("Number", StringifyResult(OneOrMore(Digit)))
+	pair := Named("Pair", SeqOf(SeqIgnore(Expect('(')), number, SeqIgnore(Expect(',')), number, SeqIgnore(Expect(')'))))
+	list := Named("List", OneOrMore(SeqOf(pair, SeqIgnore(Newline))))
+
+	{
+		r, _ := ParseRuneReader(list, strings.NewReader("(1,2)\n(3,4)\n"))
+		assert.Equal(t, []interface{}{
+			[]interface{}{[]interface{}{"1", "2"}},
+			[]interface{}{[]interface{}{"3", "4"}},
+		}, r)
+	}
+	{
+		_, err := ParseRuneReader(pair, strings.NewReader("(1,x)"))
+		assert.EqualError(t, err, `Expected "[digit]" at 1:4 (in Pair > Number)`)
+
+		pe := err.(*ParseError)
+		assert.Equal(t, []string{"Pair", "Number"}, pe.Rules)
+		assert.Equal(t, 3, pe.Offset)
+	}
+	{
+		_, err := ParseRuneReader(list, strings.NewReader("(1;"))
+		assert.EqualError(t, err, `Expected "," at 1:3 (in List > Pair)`)
+	}
+	{
+		tracer := NewTracer()
+		ParseRuneReader(pair, strings.NewReader("(1,2)"), WithTracer(tracer))
+		assert.Equal(t, "Pair", tracer.Roots()[0].Name)
+		assert.Equal(t, "Number", tracer.Roots()[0].Children[0].Children[1].Name)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	identifier := Label(StringifyResult(SeqOf(Letter, ZeroOrMore(Alphanumeric))), "identifier")
+	assignment := SeqOf(identifier, SeqIgnore(Expect('=')), Label(JSONString, "string value"))
+
+	{
+		r, _ := ParseRuneReader(assignment, strings.NewReader(`a1="b"`))
+		assert.Equal(t, []interface{}{"a1", "b"}, r)
+	}
+	{
+		_, err := ParseRuneReader(assignment, strings.NewReader(`1="b"`))
+		assert.EqualError(t, err, `Expected identifier at 1:1`)
+	}
+	{
+		_, err := ParseRuneReader(assignment, strings.NewReader(`a=`))
+		assert.EqualError(t, err, `Stream ended, expected string value at 1:3`)
+	}
+	{
+		_, err := ParseRuneReader(assignment, strings.NewReader(`a="\q"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\q" at 1:4`)
+	}
+}
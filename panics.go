@@ -0,0 +1,62 @@
+package combinators
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error of a parse interrupted by a panic, usually in a
+// user callback like the function of a Transform. The parse functions return
+// it wrapped in a ParseError located where it happened, with the stack of the
+// rules that were being parsed.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the goroutine when it panicked
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, like a runtime.Error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// parsePanic is the panic value that propagates a recovered panic to the
+// parse function, the combinators it goes through complete its error
+type parsePanic struct {
+	err error
+}
+
+// located converts a recovered value to a parsePanic at the given state,
+// values that already are parsePanics keep their position
+func located(state ParserState, r interface{}) *parsePanic {
+	if p, ok := r.(*parsePanic); ok {
+		return p
+	}
+
+	return &parsePanic{ErrorAt(state, &PanicError{r, debug.Stack()})}
+}
+
+// recoverPanic is deferred by the parse functions to return panics as errors
+func recoverPanic(state ParserState, result *interface{}, err *error) {
+	if r := recover(); r != nil {
+		*result, *err = nil, located(state, r).err
+	}
+}
+
+// applyTransform calls the function of a Transform or TransformErr, locating
+// its panics at the beginning of the transformed text
+func applyTransform(state ParserState, transform func(interface{}) (interface{}, error), value interface{}) (interface{}, error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panic(located(state, r))
+		}
+	}()
+
+	return transform(value)
+}
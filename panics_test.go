@@ -0,0 +1,70 @@
+package combinators
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformErr(t *testing.T) {
+	number := Named("Number", TransformErr(StringifyResult(OneOrMore(Alphanumeric)), func(i interface{}) (interface{}, error) {
+		return strconv.Atoi(i.(string))
+	}))
+	parser := SeqOf(SeqIgnore(ExpectString([]rune("x = "))), number)
+
+	{
+		r, err := ParseRuneReader(parser, strings.NewReader("x = 42"))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{42}, r)
+	}
+	{
+		_, err := ParseRuneReader(parser, strings.NewReader("x = 4a"))
+		assert.EqualError(t, err, `strconv.Atoi: parsing "4a": invalid syntax at 1:5 (in Number)`)
+		assert.True(t, errors.Is(err, strconv.ErrSyntax))
+	}
+	{
+		_, err := ParseRuneReader(AnyOf(number, Expect('a')), strings.NewReader("a"))
+		assert.Nil(t, err)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	value := Named("Value", Transform(Expect('b'), func(i interface{}) interface{} {
+		return i.(int)
+	}))
+	parser := Named("Pair", SeqOf(Expect('a'), value))
+
+	{
+		_, err := ParseRuneReader(parser, strings.NewReader("ab"))
+		assert.EqualError(t, err, "Panic: interface conversion: interface {} is string, not int at 1:2 (in Pair > Value)")
+
+		var pe *PanicError
+		assert.True(t, errors.As(err, &pe))
+		assert.Contains(t, string(pe.Stack), "panics_test.go")
+
+		var re runtime.Error
+		assert.True(t, errors.As(err, &re))
+	}
+	{
+		_, err := ParseRuneReader(Compile(parser), strings.NewReader("ab"))
+		assert.Equal(t, []string{"Pair", "Value"}, err.(*ParseError).Rules)
+		assert.True(t, errors.As(err, new(*PanicError)))
+	}
+	{
+		// panics outside of rules are located at the beginning of the parse
+		_, err := ParseRuneReader(SeqOf(Expect('a'), FuncParser(func(state ParserState) (*ParserResult, error) {
+			panic("not implemented")
+		})), strings.NewReader("ab"))
+		assert.EqualError(t, err, "Panic: not implemented at 1:1")
+	}
+	{
+		_, err := testTokenizer.Parse(Transform(ExpectToken("ident"), func(i interface{}) interface{} {
+			panic(errors.New("broken"))
+		}), strings.NewReader("  abc"))
+		assert.EqualError(t, err, "Panic: broken at 1:3")
+	}
+}
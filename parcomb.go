@@ -1,9 +1,11 @@
 package combinators
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -32,60 +34,271 @@ func (p FuncParser) Apply(state ParserState) (*ParserResult, error) {
 	return p(state)
 }
 
+// Locator is implemented by parser states that know their position in the input
+type Locator interface {
+	// Offset returns the index of the current element of the input
+	Offset() int
+	// GetLocation returns the zero based line and the one based column of the current position
+	GetLocation() (int, int)
+}
+
+// ParseError is an error that happened at a known position of the input
+type ParseError struct {
+	Offset int
+	Err    error
+	// Rules is the stack of Named rules the error happened in, outermost first
+	Rules []string
+
+	locator Locator
+}
+
+// ErrorAt attaches the position of the given state to an error, if the state
+// provides one, otherwise the error is returned unchanged
+func ErrorAt(state ParserState, err error) error {
+	locator, ok := state.(Locator)
+	if !ok {
+		return err
+	}
+
+	return &ParseError{Offset: locator.Offset(), Err: err, locator: locator}
+}
+
+// Location returns the one based line and column of the error, or 0, 0 if unknown
+func (e *ParseError) Location() (int, int) {
+	if e.locator == nil {
+		return 0, 0
+	}
+
+	line, col := e.locator.GetLocation()
+	return line + 1, col
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprint(e.Err)
+
+	if e.locator != nil {
+		line, col := e.Location()
+		msg += fmt.Sprintf(" at %d:%d", line, col)
+	}
+
+	if len(e.Rules) > 0 {
+		msg += fmt.Sprintf(" (in %s)", strings.Join(e.Rules, " > "))
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// textState is implemented by parser states that can return the input between them and a later state
+type textState interface {
+	textUntil(end ParserState) string
+}
+
+// ConsumedText returns the input consumed between two states of the same
+// parse, or an empty string if the states don't support it
+func ConsumedText(start, end ParserState) string {
+	if s, ok := start.(textState); ok {
+		return s.textUntil(end)
+	}
+
+	return ""
+}
+
+// runeInput is the part of a RuneScanner shared by all the states of a parse
+type runeInput struct {
+	reader  io.RuneReader
+	buffer  []rune
+	eof     bool
+	session *parseSession
+	// size is the number of bytes read
+	size int
+	// furthest is the greatest offset examined, see IncrementalParser
+	furthest int
+
+	// newlines holds the offsets of all the newlines read so far
+	newlines []int
+}
+
+// read buffers one more rune from the reader, returns false at the end of the stream
+func (in *runeInput) read() bool {
+	if in.eof {
+		return false
+	}
+
+	r, size, err := in.reader.ReadRune()
+	if err != nil {
+		in.eof = true
+		return false
+	}
+
+	in.size += size
+	if max := in.session.limits.MaxInputSize; max > 0 && in.size > max {
+		in.eof = true
+		in.session.abort(&RuneScanner{input: in, cursor: len(in.buffer)}, fmt.Errorf(`%w: more than %d bytes of input`, ErrLimitExceeded, max))
+		return false
+	}
+
+	if r == '\n' {
+		in.newlines = append(in.newlines, len(in.buffer))
+	}
+	in.buffer = append(in.buffer, r)
+
+	return true
+}
+
+// at returns the rune at the given offset reading it if needed, or 0 at the end of the stream
+func (in *runeInput) at(cursor int) rune {
+	if cursor > in.furthest {
+		in.furthest = cursor
+	}
+
+	for len(in.buffer) <= cursor {
+		if !in.read() {
+			return 0
+		}
+	}
+
+	return in.buffer[cursor]
+}
+
+// combinator is a built-in Parser, its Description makes it introspectable
+// and identifies it in traces
+type combinator struct {
+	desc Description
+	name string
+	fn   FuncParser
+}
+
+func newCombinator(desc Description, fn FuncParser) Parser {
+	return &combinator{desc, desc.String(), fn}
+}
+
+// Apply runs the combinator, recording it if the parse is traced and
+// checking it against the limits of the parse
+func (p *combinator) Apply(state ParserState) (*ParserResult, error) {
+	session := sessionOf(state)
+	if session == nil || !session.observed() && !session.guarded {
+		return p.fn(state)
+	}
+
+	if session.guarded {
+		session.depth++
+		defer func() { session.depth-- }()
+
+		if err := session.check(session.depth); err != nil {
+			return Fail(state, session.abort(state, err))
+		}
+	}
+
+	fn := p.fn
+	if session.tracer != nil {
+		fn = func(state ParserState) (*ParserResult, error) {
+			return session.tracer.record(p.name, state, p.fn)
+		}
+	}
+	if session.coverage != nil && p.desc.Kind == KindNamed {
+		traced := fn
+		fn = func(state ParserState) (*ParserResult, error) {
+			pr, err := traced(state)
+			session.coverage.count(coverageKey{p, -1}, err == nil)
+			return pr, err
+		}
+	}
+	if session.profiler != nil && p.desc.Kind == KindNamed {
+		counted := fn
+		fn = func(state ParserState) (*ParserResult, error) {
+			return session.profiler.record(session, p, p.desc.Label, state, counted)
+		}
+	}
+
+	switch {
+	case session.cst != nil:
+		return session.cst.record(p, state, fn)
+	case session.incremental != nil:
+		return session.incremental.apply(p, state, fn)
+	}
+
+	return fn(state)
+}
+
+// Describe - see Describable
+func (p *combinator) Describe() Description {
+	return p.desc
+}
+
 // RuneScanner is a basic scanner based on a RuneReader
 type RuneScanner struct {
-	reader io.RuneReader
-	buffer *[]rune
+	input  *runeInput
 	cursor int
+
+	verbatim bool
+	indents  *indentLevel
+	user     interface{}
 }
 
 // GetLocation ...
 func (s *RuneScanner) GetLocation() (int, int) {
-	lines := strings.Split(string((*s.buffer)[:s.cursor]), "\n")
-	lastLine := lines[len(lines)-1]
-	return len(lines) - 1, len(lastLine) + 1
+	end := s.cursor
+	if end > len(s.input.buffer) {
+		end = len(s.input.buffer)
+	}
+
+	line := sort.SearchInts(s.input.newlines, end)
+	if line == 0 {
+		return 0, end + 1
+	}
+
+	return line, end - s.input.newlines[line-1]
 }
 
 // CurrentRune ...
 func (s *RuneScanner) CurrentRune() rune {
-	loops := 0
+	return s.input.at(s.cursor)
+}
 
-	for len(*s.buffer) <= s.cursor {
-		r, _, err := s.reader.ReadRune()
+// Remaining ...
+func (s *RuneScanner) Remaining() ParserState {
+	next := *s
+	next.cursor++
+	return &next
+}
 
-		if err != nil {
-			return 0
-		}
+// Offset returns the index of the current rune in the input
+func (s *RuneScanner) Offset() int {
+	return s.cursor
+}
 
-		*s.buffer = append(*s.buffer, r)
-		loops++
+func (s *RuneScanner) textUntil(end ParserState) string {
+	e, ok := end.(*RuneScanner)
+	if !ok {
+		return ""
 	}
 
-	return (*s.buffer)[s.cursor]
+	from, to := s.cursor, e.cursor
+	if to > len(s.input.buffer) {
+		to = len(s.input.buffer)
+	}
+	if from > to {
+		return ""
+	}
+
+	return string(s.input.buffer[from:to])
 }
 
-// Remaining ...
-func (s *RuneScanner) Remaining() ParserState {
-	return &RuneScanner{
-		s.reader,
-		s.buffer,
-		s.cursor + 1,
-	}
+func (s *RuneScanner) parseSession() *parseSession {
+	return s.input.session
 }
 
 // PrintErrorMessage ...
 func (s *RuneScanner) PrintErrorMessage(e error) {
-	var r rune
-	var err error
-
-	r, _, err = s.reader.ReadRune()
-
-	for err == nil {
-		*s.buffer = append(*s.buffer, r)
-		r, _, err = s.reader.ReadRune()
+	for s.input.read() {
 	}
 
-	lines := strings.Split(string(*s.buffer), "\n")
+	lines := strings.Split(string(s.input.buffer), "\n")
 
 	line, col := s.GetLocation()
 
@@ -99,14 +312,81 @@ func (s *RuneScanner) PrintErrorMessage(e error) {
 	fmt.Fprintf(os.Stderr, "  %s^\n", strings.Repeat(" ", col))
 }
 
+// ParseOption configures a single parse
+type ParseOption func(*parseSession)
+
+// parseSession holds the options and the data shared by all the states of a single parse
+type parseSession struct {
+	userState   interface{}
+	tracer      *Tracer
+	cst         *cstBuilder
+	cstLabels   bool
+	incremental *IncrementalParser
+	coverage    *Coverage
+	profiler    *Profiler
+	noDispatch  bool
+
+	ctx    context.Context
+	limits Limits
+	// guarded tells the steps must be checked against the context and the limits
+	guarded bool
+	steps   int
+	depth   int
+
+	memo      map[memoKey]memoEntry
+	memoBytes int
+
+	// err aborts the parse, it can't be recovered by backtracking
+	err error
+}
+
+// observed tells the applications of the combinators are recorded, compiled
+// Programs are interpreted to record them too
+func (session *parseSession) observed() bool {
+	return session.tracer != nil || session.cst != nil || session.incremental != nil || session.coverage != nil || session.profiler != nil
+}
+
+func newParseSession(opts []ParseOption) *parseSession {
+	session := &parseSession{}
+	for _, opt := range opts {
+		opt(session)
+	}
+	// any limit can abort the parse, the aborted parses must stop right away
+	session.guarded = session.ctx != nil || session.limits != Limits{}
+
+	return session
+}
+
+// sessionState is implemented by parser states that belong to a parse session
+type sessionState interface {
+	parseSession() *parseSession
+}
+
+// sessionOf returns the session of a state, or nil if the state doesn't have one
+func sessionOf(state ParserState) *parseSession {
+	if s, ok := state.(sessionState); ok {
+		return s.parseSession()
+	}
+
+	return nil
+}
+
 // ParseRuneReader - trivial
-func ParseRuneReader(parser Parser, r io.RuneReader) (interface{}, error) {
-	s := &RuneScanner{r, &[]rune{}, 0}
+func ParseRuneReader(parser Parser, r io.RuneReader, opts ...ParseOption) (interface{}, error) {
+	result, _, err := parseRunes(parser, r, newParseSession(opts))
+	return result, err
+}
+
+// parseRunes parses the runes of a reader, it also returns the final state
+func parseRunes(parser Parser, r io.RuneReader, session *parseSession) (result interface{}, end *RuneScanner, err error) {
+	s := &RuneScanner{input: &runeInput{reader: r, session: session}, user: session.userState}
+	defer recoverPanic(s, &result, &err)
 
 	pr, err := parser.Apply(s)
-	if err != nil {
-		return nil, err
+	if result, err = session.result(pr, err); err != nil {
+		return nil, nil, err
 	}
 
-	return pr.Result, nil
+	end, _ = pr.Remaining.(*RuneScanner)
+	return result, end, nil
 }
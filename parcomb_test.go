@@ -272,3 +272,26 @@ func BenchmarkDecimal2(b *testing.B) {
 // 	log.Printf("%+v", r)
 // 	// Output: ["aaaa", "aaaaa", &Partial{"aaa"}, "aaaaa", "aa"]
 // }
+
+func TestLookahead(t *testing.T) {
+	{
+		parser := StringifyResult(OneOrMore(SeqOf(SeqIgnore(Not(ExpectString([]rune("*/")))), Any)))
+
+		r, err := ParseRuneReader(parser, strings.NewReader("a*b*/"))
+		assert.Nil(t, err)
+		assert.Equal(t, "a*b", r)
+
+		_, err = ParseRuneReader(Not(Expect('a')), strings.NewReader("ab"))
+		assert.EqualError(t, err, `Unexpected "a" at 1:1`)
+	}
+	{
+		parser := SeqOf(Lookahead(ExpectString([]rune("ab"))), Expect('a'))
+
+		r, err := ParseRuneReader(parser, strings.NewReader("ab"))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"ab", "a"}, r)
+
+		_, err = ParseRuneReader(parser, strings.NewReader("ac"))
+		assert.EqualError(t, err, `Expected "b"`)
+	}
+}
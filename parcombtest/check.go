@@ -0,0 +1,88 @@
+package parcombtest
+
+import (
+	"errors"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/aziis98/parser-combinators/syntax"
+)
+
+// TB is the part of testing.TB used by the checks, so they can be tested too
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// DefaultLimits are the limits of the parses of Check, a parse exceeding
+// them most likely doesn't terminate. They are overridden by passing
+// c.WithLimits to Check.
+var DefaultLimits = c.Limits{MaxSteps: 1000000, MaxDepth: 10000}
+
+// Check parses an input, usually one of a fuzz test, and fails the test if
+// the parse panics or exceeds the DefaultLimits, like the repetitions of
+// parsers that succeed without consuming input and the left recursive rules
+// do. Parse errors are expected for random inputs and are not failures.
+func Check(t TB, parser c.Parser, input string, opts ...c.ParseOption) {
+	t.Helper()
+	check(t, parser, input, opts)
+}
+
+func check(t TB, parser c.Parser, input string, opts []c.ParseOption) (interface{}, error) {
+	t.Helper()
+
+	opts = append([]c.ParseOption{c.WithLimits(DefaultLimits)}, opts...)
+	result, err := c.ParseRuneReader(parser, strings.NewReader(input), opts...)
+
+	var pe *c.PanicError
+	if errors.As(err, &pe) {
+		t.Fatalf("Parse of %q panicked: %v\n%s", input, err, pe.Stack)
+	}
+
+	if errors.Is(err, c.ErrLimitExceeded) {
+		t.Fatalf("Parse of %q doesn't terminate: %v%s", input, err, progressHints(parser))
+	}
+
+	return result, err
+}
+
+// CheckProgress fails the test if the grammar has parsers that can stop
+// making progress, that are the repetitions of parsers that succeed without
+// consuming input and the left recursive rules found by Analyze. Check finds
+// the ones Analyze can't see, like the ones in FuncParsers, when a parse
+// exceeds its limits.
+func CheckProgress(t TB, parser c.Parser) {
+	t.Helper()
+
+	if hints := progressHints(parser); hints != "" {
+		t.Fatalf("Grammar can stop making progress:%s", hints)
+	}
+}
+
+// progressHints lists the warnings of Analyze about parsers that can stop making progress
+func progressHints(parser c.Parser) string {
+	hints := []string{}
+	for _, w := range c.Analyze(parser).Warnings {
+		if w.Kind == c.NullableLoop || w.Kind == c.LeftRecursion {
+			hints = append(hints, "\n - "+w.String())
+		}
+	}
+
+	return strings.Join(hints, "")
+}
+
+// CheckRoundTrip checks a parse of an input like Check and, if the syntax
+// accepts the whole input, that printing the parsed value and parsing it back
+// results in the same value, see syntax.RoundTrip
+func CheckRoundTrip(t TB, s syntax.Syntax, input string, opts ...c.ParseOption) {
+	t.Helper()
+
+	value, err := check(t, syntax.Whole(s), input, opts)
+	if err != nil {
+		return
+	}
+
+	if err := syntax.RoundTrip(s, value, opts...); err != nil {
+		t.Fatalf("Round trip of the value parsed from %q failed: %v", input, err)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build go1.18
+// +build go1.18
+
+package parcombtest
+
+import "testing"
+
+func FuzzExpr(f *testing.F) {
+	if err := AddCorpus(f, NewGenerator(expr, 1), 50); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		Check(t, expr, input)
+	})
+}
@@ -0,0 +1,297 @@
+// Package parcombtest helps testing grammars built with this module: a
+// Generator produces random inputs accepted by a grammar from the
+// Descriptions of its combinators, Check asserts a parse of any input
+// terminates without panicking, for the seed corpora and the fuzz targets of
+// "go test -fuzz", and CheckRoundTrip does the same for syntaxes that can
+// print their values. CheckProgress asserts a grammar always makes progress
+// with the analysis of Analyze, Check detects the loops it can't see through
+// the ErrLimitExceeded of the parses. A fuzz test of a grammar, that needs Go
+// 1.18 or later, looks like
+//
+//	func FuzzGrammar(f *testing.F) {
+//		if err := parcombtest.AddCorpus(f, parcombtest.NewGenerator(grammar, 1), 100); err != nil {
+//			f.Fatal(err)
+//		}
+//
+//		f.Fuzz(func(t *testing.T, input string) {
+//			parcombtest.Check(t, grammar, input)
+//		})
+//	}
+package parcombtest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// DefaultAlphabet are the runes tried for the ExpectPredicate parsers
+var DefaultAlphabet = []rune(" \t\n!\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~àé€😀")
+
+// Generator produces random inputs from a grammar. It supports the terminals
+// matching known text, the combinators of sequences, alternatives and
+// repetitions and the ones wrapping a single parser like Named and
+// Transform. Lookaheads and Trivia produce nothing or a space, so the
+// candidates are checked with the grammar and only the accepted ones are
+// returned.
+type Generator struct {
+	// MaxDepth is the nesting of parsers after which the generator takes the
+	// shortest way out of recursive rules
+	MaxDepth int
+	// MaxRepeat is the maximum number of repetitions of ZeroOrMore, OneOrMore and RepeatUntil
+	MaxRepeat int
+	// Attempts is the number of candidates tried by Generate
+	Attempts int
+	// Alphabet are the runes tried for the ExpectPredicate parsers
+	Alphabet []rune
+
+	parser  c.Parser
+	rand    *rand.Rand
+	heights heights
+	runes   map[c.Parser][]rune
+}
+
+// NewGenerator creates a Generator of inputs for the given parser, generators
+// with the same seed produce the same inputs
+func NewGenerator(parser c.Parser, seed int64) *Generator {
+	return &Generator{
+		MaxDepth:  30,
+		MaxRepeat: 3,
+		Attempts:  100,
+		Alphabet:  DefaultAlphabet,
+
+		parser: parser,
+		rand:   rand.New(rand.NewSource(seed)),
+		runes:  map[c.Parser][]rune{},
+	}
+}
+
+// Generate returns a random input accepted entirely by the parser
+func (g *Generator) Generate() (string, error) {
+	if g.heights == nil {
+		g.heights = newHeights(g.parser)
+	}
+
+	whole := c.SeqOf(g.parser, c.EOF)
+	for i := 0; i < g.Attempts; i++ {
+		var sb strings.Builder
+		if err := g.generate(&sb, g.parser, 0); err != nil {
+			return "", err
+		}
+
+		input := sb.String()
+		if _, err := c.ParseRuneReader(whole, strings.NewReader(input)); err == nil {
+			return input, nil
+		}
+	}
+
+	return "", fmt.Errorf(`Cannot generate an input accepted by the parser in %d attempts`, g.Attempts)
+}
+
+func (g *Generator) generate(sb *strings.Builder, parser c.Parser, depth int) error {
+	desc := c.Describe(parser)
+	limited := depth >= g.MaxDepth
+
+	switch desc.Kind {
+	case c.KindExpect, c.KindExpectString:
+		sb.WriteString(desc.Text)
+
+	case c.KindExpectAny:
+		runes := []rune(desc.Text)
+		sb.WriteRune(runes[g.rand.Intn(len(runes))])
+
+	case c.KindExpectPredicate:
+		runes, ok := g.runes[parser]
+		if !ok {
+			for _, r := range g.Alphabet {
+				if desc.Predicate(r) {
+					runes = append(runes, r)
+				}
+			}
+			g.runes[parser] = runes
+		}
+
+		if len(runes) == 0 {
+			return fmt.Errorf(`Cannot generate inputs for %s, no rune of the alphabet matches`, desc)
+		}
+		sb.WriteRune(runes[g.rand.Intn(len(runes))])
+
+	case c.KindEOF, c.KindNot, c.KindLookahead:
+
+	case c.KindTrivia:
+		if !limited && g.rand.Intn(2) == 0 {
+			sb.WriteRune(' ')
+		}
+
+	case c.KindSeqOf:
+		for _, child := range desc.Children {
+			if err := g.generate(sb, child, depth+1); err != nil {
+				return err
+			}
+		}
+
+	case c.KindAnyOf:
+		alternatives := []c.Parser{}
+		for _, child := range desc.Children {
+			if g.heights.of(child) < math.MaxInt32 {
+				alternatives = append(alternatives, child)
+			}
+		}
+		if len(alternatives) == 0 {
+			return fmt.Errorf(`Cannot generate inputs for %s, no alternative is supported`, desc)
+		}
+
+		alternative := alternatives[g.rand.Intn(len(alternatives))]
+		if limited {
+			// the shortest way out of recursive rules
+			for _, child := range alternatives {
+				if g.heights.of(child) < g.heights.of(alternative) {
+					alternative = child
+				}
+			}
+		}
+
+		return g.generate(sb, alternative, depth+1)
+
+	case c.KindZeroOrMore, c.KindOneOrMore, c.KindOptional, c.KindRepeatUntil:
+		max, min := g.MaxRepeat, 0
+		switch desc.Kind {
+		case c.KindOneOrMore:
+			min = 1
+		case c.KindOptional:
+			max = 1
+		}
+
+		n := min
+		if !limited {
+			n += g.rand.Intn(max - min + 1)
+		}
+
+		for i := 0; i < n; i++ {
+			if err := g.generate(sb, desc.Children[0], depth+1); err != nil {
+				return err
+			}
+		}
+
+		if desc.Kind == c.KindRepeatUntil {
+			return g.generate(sb, desc.Children[1], depth+1)
+		}
+
+	case c.KindSeqIgnore, c.KindTransform, c.KindTransformErr, c.KindLazy, c.KindMemoize,
+		c.KindNamed, c.KindLabel, c.KindLexeme, c.KindVerbatim:
+		return g.generate(sb, desc.Children[0], depth+1)
+
+	default:
+		return fmt.Errorf(`Cannot generate inputs for %s`, desc)
+	}
+
+	return nil
+}
+
+// heights are the least nesting of the inputs generated by the parsers of a grammar
+type heights map[c.Parser]int
+
+// of returns the height of a parser, math.MaxInt32 for the unsupported ones
+func (h heights) of(p c.Parser) int {
+	if !reflect.TypeOf(p).Comparable() {
+		return math.MaxInt32
+	}
+
+	if height, ok := h[p]; ok {
+		return height
+	}
+
+	return math.MaxInt32
+}
+
+func newHeights(root c.Parser) heights {
+	h := heights{}
+	descs := map[c.Parser]c.Description{}
+
+	var visit func(p c.Parser)
+	visit = func(p c.Parser) {
+		if !reflect.TypeOf(p).Comparable() {
+			return
+		}
+		if _, ok := descs[p]; ok {
+			return
+		}
+
+		descs[p] = c.Describe(p)
+		h[p] = math.MaxInt32
+		for _, child := range descs[p].Children {
+			visit(child)
+		}
+	}
+	visit(root)
+
+	height := func(desc c.Description) int {
+		switch desc.Kind {
+		case c.KindExpect, c.KindExpectString, c.KindExpectAny, c.KindExpectPredicate,
+			c.KindEOF, c.KindNot, c.KindLookahead, c.KindTrivia, c.KindZeroOrMore, c.KindOptional:
+			return 0
+
+		case c.KindSeqOf:
+			max := 0
+			for _, child := range desc.Children {
+				if h.of(child) > max {
+					max = h.of(child)
+				}
+			}
+			return max
+
+		case c.KindAnyOf:
+			min := math.MaxInt32
+			for _, child := range desc.Children {
+				if h.of(child) < min {
+					min = h.of(child)
+				}
+			}
+			return min
+
+		case c.KindRepeatUntil:
+			return h.of(desc.Children[1])
+
+		case c.KindOneOrMore, c.KindSeqIgnore, c.KindTransform, c.KindTransformErr, c.KindLazy, c.KindMemoize,
+			c.KindNamed, c.KindLabel, c.KindLexeme, c.KindVerbatim:
+			return h.of(desc.Children[0])
+		}
+
+		return math.MaxInt32
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for p, desc := range descs {
+			if next := height(desc); next < math.MaxInt32 && next+1 < h[p] {
+				h[p] = next + 1
+				changed = true
+			}
+		}
+	}
+
+	return h
+}
+
+// Corpus is the seed corpus of a fuzz test, like a *testing.F
+type Corpus interface {
+	Add(args ...interface{})
+}
+
+// AddCorpus adds n generated inputs to the seed corpus of a fuzz test
+func AddCorpus(corpus Corpus, g *Generator, n int) error {
+	for i := 0; i < n; i++ {
+		input, err := g.Generate()
+		if err != nil {
+			return err
+		}
+
+		corpus.Add(input)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package parcombtest
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// update is prefixed with the package name, so it doesn't clash with the
+// -update flags of the tests importing the package
+var update = flag.Bool("parcombtest.update", false, "rewrite the golden files of the tests using parcombtest.Golden")
+
+// Golden parses each file in a directory and its subdirectories, like
+// "testdata/golden", and compares the outcome with the file of the same name
+// plus the ".golden" extension, in a subtest for each file. The outcome is
+// the result of the parse as indented JSON or its errors, one per line.
+// Running the tests with the -parcombtest.update flag writes the golden files
+// instead, so adding a test case is adding an input file and reviewing its
+// golden file. Use EOF in the parser to require the whole files to be parsed.
+func Golden(t *testing.T, parser c.Parser, dir string, opts ...c.ParseOption) {
+	t.Helper()
+
+	inputs := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && !strings.HasPrefix(info.Name(), ".") && filepath.Ext(path) != ".golden" {
+			inputs = append(inputs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatalf("No input files in %s", dir)
+	}
+
+	for _, path := range inputs {
+		path := path
+		name, _ := filepath.Rel(dir, path)
+
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			input, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := GoldenOutput(parser, string(input), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := path + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expected, err := ioutil.ReadFile(golden)
+			if os.IsNotExist(err) {
+				t.Fatalf("Missing golden file %s, run the tests with -parcombtest.update to create it", golden)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expected) != actual {
+				t.Errorf("Outcome of %s differs from %s, run the tests with -parcombtest.update to accept it\n--- expected\n%s+++ actual\n%s", path, golden, expected, actual)
+			}
+		})
+	}
+}
+
+// GoldenOutput returns the outcome of a parse as written in golden files:
+// the result as indented JSON or the errors, one per line
+func GoldenOutput(parser c.Parser, input string, opts ...c.ParseOption) (string, error) {
+	result, err := c.ParseRuneReader(parser, strings.NewReader(input), opts...)
+	if err != nil {
+		var sb strings.Builder
+		for _, e := range errorList(err) {
+			fmt.Fprintf(&sb, "error: %v\n", e)
+		}
+
+		return sb.String(), nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf(`Cannot serialize the result as JSON: %w`, err)
+	}
+
+	return string(data) + "\n", nil
+}
+
+// errorList returns the errors joined in an error, like the ones of errors.Join
+func errorList(err error) []error {
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := []error{}
+		for _, e := range multi.Unwrap() {
+			errs = append(errs, errorList(e)...)
+		}
+		return errs
+	}
+
+	return []error{err}
+}
@@ -0,0 +1,192 @@
+package parcombtest
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/aziis98/parser-combinators/syntax"
+	"github.com/stretchr/testify/assert"
+)
+
+var expr c.Parser
+
+var exprRef = c.Lazy(func() c.Parser {
+	return expr
+})
+
+func init() {
+	value := c.Named("Value", c.AnyOf(
+		c.OneOrMore(c.Digit),
+		c.SeqOf(c.Expect('('), exprRef, c.Expect(')')),
+	))
+	product := c.Named("Product", c.SeqOf(value, c.ZeroOrMore(c.SeqOf(c.ExpectAny([]rune("*/")), value))))
+	expr = c.Named("Sum", c.SeqOf(product, c.ZeroOrMore(c.SeqOf(c.ExpectAny([]rune("+-")), product))))
+}
+
+// recorder records the failures of the checks
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator(expr, 1)
+
+	inputs := map[string]bool{}
+	nested := false
+	for i := 0; i < 100; i++ {
+		input, err := g.Generate()
+		assert.Nil(t, err)
+
+		_, err = c.ParseRuneReader(c.SeqOf(expr, c.EOF), strings.NewReader(input))
+		assert.Nil(t, err)
+
+		inputs[input] = true
+		nested = nested || strings.Contains(input, "(")
+	}
+	assert.True(t, nested)
+	assert.Greater(t, len(inputs), 50)
+
+	// the same seed generates the same inputs
+	a, b := NewGenerator(expr, 7), NewGenerator(expr, 7)
+	for i := 0; i < 10; i++ {
+		inputA, _ := a.Generate()
+		inputB, _ := b.Generate()
+		assert.Equal(t, inputA, inputB)
+	}
+
+	{
+		skipper := &c.Skipper{}
+		word := skipper.Lexeme(c.OneOrMore(c.Letter))
+		words := c.SeqOf(word, c.ZeroOrMore(c.SeqOf(skipper.Symbol(","), word)), c.Not(c.Any))
+
+		input, err := NewGenerator(words, 1).Generate()
+		assert.Nil(t, err)
+		_, err = c.ParseRuneReader(words, strings.NewReader(input))
+		assert.Nil(t, err)
+	}
+	{
+		_, err := NewGenerator(c.SeqOf(c.Expect('a'), c.FuncParser(nil)), 1).Generate()
+		assert.EqualError(t, err, `Cannot generate inputs for Opaque(combinators.FuncParser)`)
+
+		g := NewGenerator(c.Digit, 1)
+		g.Alphabet = []rune("abc")
+		_, err = g.Generate()
+		assert.EqualError(t, err, `Cannot generate inputs for ExpectPredicate(digit), no rune of the alphabet matches`)
+
+		_, err = NewGenerator(c.SeqOf(c.Expect('a'), c.Expect('b'), c.Not(c.Expect('b'))), 1).Generate()
+		assert.Nil(t, err)
+
+		_, err = NewGenerator(c.SeqOf(c.Expect('a'), c.Not(c.Expect('b')), c.Expect('b')), 1).Generate()
+		assert.EqualError(t, err, `Cannot generate an input accepted by the parser in 100 attempts`)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	{
+		r := &recorder{}
+		Check(r, expr, "1+(2")
+		Check(r, expr, "")
+		assert.Empty(t, r.failures)
+	}
+	{
+		r := &recorder{}
+		Check(r, c.ZeroOrMore(c.Optional(c.Expect('a'))), "b")
+		assert.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], `Parse of "b" doesn't terminate: Limit exceeded: more than 1000000 steps`)
+		assert.Contains(t, r.failures[0], `nullable loop: `)
+
+		r = &recorder{}
+		Check(r, c.ZeroOrMore(c.Optional(c.Expect('a'))), "b", c.WithLimits(c.Limits{MaxSteps: 100}))
+		assert.Contains(t, r.failures[0], `more than 100 steps`)
+	}
+	{
+		r := &recorder{}
+		Check(r, c.Transform(c.Any, func(i interface{}) interface{} {
+			return i.([]interface{})
+		}), "x")
+		assert.Len(t, r.failures, 1)
+		assert.True(t, strings.HasPrefix(r.failures[0], `Parse of "x" panicked: Panic: interface conversion`))
+	}
+}
+
+func TestCheckProgress(t *testing.T) {
+	r := &recorder{}
+	CheckProgress(r, expr)
+	assert.Empty(t, r.failures)
+
+	CheckProgress(r, c.Named("Lines", c.ZeroOrMore(c.RepeatUntil(c.Any, c.Newline))))
+	assert.Equal(t, []string{"Grammar can stop making progress:\n - nullable loop in Lines: ZeroOrMore repeats RepeatUntil that can succeed without consuming input"}, r.failures)
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	word := syntax.Chars(unicode.IsLetter, "letter")
+	words := syntax.SepBy(word, syntax.Seq(syntax.Literal(","), syntax.Skip(c.ZeroOrMore(c.Expect(' ')), " ")))
+
+	r := &recorder{}
+	CheckRoundTrip(r, words, "a,b,   cd")
+	CheckRoundTrip(r, words, "a,,b")
+	CheckRoundTrip(r, syntax.Literal("x"), "x")
+	assert.Empty(t, r.failures)
+
+	lossy := syntax.Transform(word, syntax.Iso{
+		Apply: func(i interface{}) (interface{}, error) {
+			return strings.Repeat(i.(string), 2), nil
+		},
+		Unapply: func(i interface{}) (interface{}, error) {
+			return i, nil
+		},
+	})
+	CheckRoundTrip(r, lossy, "go")
+	assert.Equal(t, []string{`Round trip of the value parsed from "go" failed: Round trip of "gogo" printed "gogo" and parsed back "gogogogo"`}, r.failures)
+}
+
+var numbers = c.SeqOf(
+	c.StringifyResult(c.OneOrMore(c.Digit)),
+	c.ZeroOrMore(c.Unwrap(c.SeqOf(c.SeqIgnore(c.Expect(',')), c.StringifyResult(c.OneOrMore(c.Digit))))),
+	c.SeqIgnore(c.EOF),
+)
+
+// the tests of the packages using Golden can have their own -update flag
+var _ = flag.Bool("update", false, "an -update flag of the tests")
+
+func TestGolden(t *testing.T) {
+	Golden(t, numbers, "testdata/golden")
+}
+
+func TestGoldenOutput(t *testing.T) {
+	output, err := GoldenOutput(numbers, "1,2")
+	assert.Nil(t, err)
+	assert.Equal(t, "[\n    \"1\",\n    [\n        \"2\"\n    ]\n]\n", output)
+
+	output, err = GoldenOutput(c.FuncParser(func(state c.ParserState) (*c.ParserResult, error) {
+		return nil, joined{fmt.Errorf(`First`), joined{fmt.Errorf(`Second`)}}
+	}), "x")
+	assert.Nil(t, err)
+	assert.Equal(t, "error: First\nerror: Second\n", output)
+
+	_, err = GoldenOutput(c.Transform(c.Any, func(interface{}) interface{} {
+		return func() {}
+	}), "x")
+	assert.EqualError(t, err, `Cannot serialize the result as JSON: json: unsupported type: func()`)
+}
+
+// joined is an error made of many errors
+type joined []error
+
+func (j joined) Error() string {
+	return "many errors"
+}
+
+func (j joined) Unwrap() []error {
+	return j
+}
@@ -0,0 +1,134 @@
+// Package peg loads grammars written as Parsing Expression Grammars and
+// builds them with the combinators of this module at runtime. A grammar is a
+// list of rules like
+//
+//	# Comments start with "#"
+//	Heading <- '#'+ ' ' (!'\n' .)*
+//	Word    <- [a-zA-Z_] [a-zA-Z0-9_]*
+//
+// The first rule is the start rule. Rules can reference each other in any
+// order and can be recursive, but not left recursive: use
+// combinators.Analyze to check a grammar.
+//
+// Expressions are, from the loosest to the tightest:
+//
+//	e1 / e2   ordered choice, results in the result of the matching alternative
+//	e1 e2     sequence, results in a []interface{} of the results of its items
+//	!e  &e    negative and positive lookahead, their results are not part of sequences
+//	e? e* e+  optional (nil when missing), zero or more and one or more ([]interface{})
+//	(e)       grouping
+//	'x' "x"   literal string, with the escapes \n \r \t \' \" \[ \] \\ \- and \uXXXX
+//	[a-z_]    character class, negated by a leading ^, results in a string
+//	.         any character
+//	Rule      reference to a rule, results in the result of the rule
+//
+// A sequence with a single item besides lookaheads results in the result of
+// that item. Semantic actions are attached to rules by name and transform
+// their results.
+package peg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// Actions are semantic actions by rule name, each one transforms the result
+// of its rule as combinators.Transform does
+type Actions map[string]func(interface{}) interface{}
+
+// Grammar is a loaded grammar
+type Grammar struct {
+	// Start is the name of the first rule of the grammar
+	Start string
+	// Rules are the parsers of the rules by name, each one is Named after its rule
+	Rules map[string]c.Parser
+}
+
+// LoadGrammar reads a grammar and builds a Parser for each of its rules,
+// actions can be nil
+func LoadGrammar(r io.Reader, actions Actions) (*Grammar, error) {
+	result, err := c.ParseRuneReader(grammarSyntax, bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := result.([]*definition)
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf(`Empty grammar`)
+	}
+
+	g := &Grammar{Start: definitions[0].name, Rules: map[string]c.Parser{}}
+	l := &loader{rules: g.Rules}
+
+	for _, def := range definitions {
+		if _, ok := g.Rules[def.name]; ok {
+			return nil, fmt.Errorf(`Rule "%s" is defined more than once`, def.name)
+		}
+
+		l.rule = def.name
+		body := def.body(l)
+		if action, ok := actions[def.name]; ok {
+			body = c.Transform(body, action)
+		}
+
+		g.Rules[def.name] = c.Named(def.name, body)
+	}
+
+	for _, ref := range l.references {
+		if _, ok := g.Rules[ref.to]; !ok {
+			return nil, fmt.Errorf(`Undefined rule "%s" referenced by "%s"`, ref.to, ref.from)
+		}
+	}
+
+	names := []string{}
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := g.Rules[name]; !ok {
+			return nil, fmt.Errorf(`Action for undefined rule "%s"`, name)
+		}
+	}
+
+	return g, nil
+}
+
+// Parse parses the input with the start rule
+func (g *Grammar) Parse(r io.RuneReader, opts ...c.ParseOption) (interface{}, error) {
+	return c.ParseRuneReader(g.Rules[g.Start], r, opts...)
+}
+
+// builder builds the parser of an expression once all the rules are known
+type builder func(l *loader) c.Parser
+
+type definition struct {
+	name string
+	body builder
+}
+
+type reference struct {
+	from, to string
+}
+
+// loader holds the rules being built, references are resolved lazily so
+// rules can be recursive and defined in any order
+type loader struct {
+	rules map[string]c.Parser
+	// rule is the name of the rule being built
+	rule       string
+	references []reference
+}
+
+func (l *loader) reference(name string) c.Parser {
+	l.references = append(l.references, reference{l.rule, name})
+
+	return c.Lazy(func() c.Parser {
+		return l.rules[name]
+	})
+}
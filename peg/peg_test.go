@@ -0,0 +1,130 @@
+package peg
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/stretchr/testify/assert"
+)
+
+const arithmetic = `
+# Integer arithmetic without spaces
+Expr    <- Sum !.
+Sum     <- Product (AddOp Product)*
+Product <- Value ([*/] Value)*
+Value   <- Number / '(' Sum ')'
+Number  <- '-'? [0-9]+
+AddOp   <- "+" / "-"
+`
+
+func fold(i interface{}) interface{} {
+	seq := i.([]interface{})
+	result := seq[0].(int)
+
+	for _, iOp := range seq[1].([]interface{}) {
+		op := iOp.([]interface{})
+		switch value := op[1].(int); op[0] {
+		case "+":
+			result += value
+		case "-":
+			result -= value
+		case "*":
+			result *= value
+		case "/":
+			result /= value
+		}
+	}
+
+	return result
+}
+
+func TestLoadGrammar(t *testing.T) {
+	g, err := LoadGrammar(strings.NewReader(arithmetic), Actions{
+		"Sum":     fold,
+		"Product": fold,
+		"Value": func(i interface{}) interface{} {
+			if seq, ok := i.([]interface{}); ok {
+				return seq[1]
+			}
+			return i
+		},
+		"Number": func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			n, _ := strconv.Atoi(c.StringifyInterfaces(seq[1]))
+			if seq[0] != nil {
+				return -n
+			}
+			return n
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Expr", g.Start)
+	assert.Len(t, g.Rules, 6)
+
+	{
+		r, err := g.Parse(strings.NewReader("2*(3+-4)-10/5"))
+		assert.Nil(t, err)
+		assert.Equal(t, -4, r)
+	}
+	{
+		r, err := c.ParseRuneReader(g.Rules["AddOp"], strings.NewReader("-"))
+		assert.Nil(t, err)
+		assert.Equal(t, "-", r)
+	}
+	{
+		_, err := g.Parse(strings.NewReader("1+2)"))
+		assert.EqualError(t, err, `Unexpected ")" at 1:4 (in Expr)`)
+	}
+
+	assert.Nil(t, c.Analyze(g.Rules[g.Start]).Err())
+}
+
+func TestExpressions(t *testing.T) {
+	g, err := LoadGrammar(strings.NewReader(`
+		Heading  <- '#'+ ' ' (!'\n' .)*
+		Class    <- [^a-c\]\n] [à-èx-]
+		Peek     <- &'ab' 'a'
+		Optional <- 'x'? ''
+		Empty    <- ('a' / )
+	`), nil)
+	assert.Nil(t, err)
+
+	parse := func(rule, input string) interface{} {
+		r, err := c.ParseRuneReader(g.Rules[rule], strings.NewReader(input))
+		assert.Nil(t, err)
+		return r
+	}
+
+	assert.Equal(t, []interface{}{[]interface{}{"#", "#"}, " ", []interface{}{"T", "i", "t"}}, parse("Heading", "## Tit\nle"))
+	assert.Equal(t, []interface{}{"z", "è"}, parse("Class", "zè"))
+	assert.Equal(t, []interface{}{"d", "-"}, parse("Class", "d-"))
+	assert.Equal(t, "a", parse("Peek", "ab"))
+	assert.Equal(t, []interface{}{nil, ""}, parse("Optional", "y"))
+	assert.Equal(t, []interface{}{}, parse("Empty", "b"))
+
+	_, err = c.ParseRuneReader(g.Rules["Class"], strings.NewReader("b"))
+	assert.EqualError(t, err, `Expected "[[^a-c\]\n]]" at 1:1 (in Class)`)
+
+	_, err = c.ParseRuneReader(g.Rules["Peek"], strings.NewReader("ac"))
+	assert.NotNil(t, err)
+}
+
+func TestGrammarErrors(t *testing.T) {
+	load := func(grammar string, actions Actions) error {
+		_, err := LoadGrammar(strings.NewReader(grammar), actions)
+		return err
+	}
+
+	assert.EqualError(t, load("# nothing\n", nil), `Empty grammar`)
+	assert.EqualError(t, load("A <- B\nA <- 'a'", nil), `Rule "A" is defined more than once`)
+	assert.EqualError(t, load("A <- 'a' B", nil), `Undefined rule "B" referenced by "A"`)
+	assert.EqualError(t, load("A <- 'a'", Actions{"B": nil}), `Action for undefined rule "B"`)
+	assert.EqualError(t, load("A 'a'", nil), `Expected "<-" at 1:3 (in Grammar > Definition)`)
+	assert.EqualError(t, load("A <- ('a' 'b'\n", nil), `Expected rule name at 1:6 (in Grammar > Definition)`)
+
+	g, err := LoadGrammar(strings.NewReader("A <- A 'x' / 'y'"), nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.Analyze(g.Rules["A"]).Err())
+}
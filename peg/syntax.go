@@ -0,0 +1,288 @@
+package peg
+
+import (
+	"strconv"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// escapes are the escape sequences of literals and character classes besides \uXXXX
+var escapes = map[rune]rune{
+	'n': '\n', 'r': '\r', 't': '\t',
+	'\'': '\'', '"': '"', '[': '[', ']': ']', '\\': '\\', '-': '-', '^': '^',
+}
+
+var pegQuotes = c.QuoteStyle{
+	Name:           "PEG literal",
+	Delimiters:     []string{`'`, `"`},
+	Backslash:      true,
+	SimpleEscapes:  escapes,
+	UnicodeEscapes: true,
+}
+
+var skipper = &c.Skipper{LineComments: []string{"#"}}
+
+var identifier = c.Label(
+	skipper.Lexeme(
+		c.StringifyResult(
+			c.SeqOf(
+				c.AnyOf(c.Letter, c.Expect('_')),
+				c.ZeroOrMore(c.AnyOf(c.Letter, c.Digit, c.Expect('_'))),
+			),
+		),
+	),
+	"rule name",
+)
+
+var leftArrow = c.Label(skipper.Symbol("<-"), `"<-"`)
+
+var hexDigit = c.ExpectAny([]rune("0123456789abcdefABCDEF"))
+
+// classChar is a possibly escaped rune of a character class
+var classChar = c.AnyOf(
+	c.Transform(
+		c.SeqOf(c.SeqIgnore(c.ExpectString([]rune(`\u`))), hexDigit, hexDigit, hexDigit, hexDigit),
+		func(i interface{}) interface{} {
+			code, _ := strconv.ParseUint(c.StringifyInterfaces(i), 16, 32)
+			return rune(code)
+		},
+	),
+	c.Transform(
+		c.SeqOf(c.SeqIgnore(c.Expect('\\')), c.ExpectPredicate(func(r rune) bool {
+			_, ok := escapes[r]
+			return ok
+		}, "escape")),
+		func(i interface{}) interface{} {
+			return escapes[[]rune(i.([]interface{})[0].(string))[0]]
+		},
+	),
+	c.Transform(
+		c.SeqOf(c.SeqIgnore(c.Not(c.ExpectAny([]rune("\\]\n")))), c.Any),
+		func(i interface{}) interface{} {
+			return []rune(i.([]interface{})[0].(string))[0]
+		},
+	),
+)
+
+var classRangeSyntax = c.Transform(
+	c.SeqOf(classChar, c.Optional(c.SeqOf(c.SeqIgnore(c.Expect('-')), classChar))),
+	func(i interface{}) interface{} {
+		seq := i.([]interface{})
+		lo := seq[0].(rune)
+		if seq[1] == nil {
+			return c.RuneRange{Lo: lo, Hi: lo}
+		}
+
+		return c.RuneRange{Lo: lo, Hi: seq[1].([]interface{})[0].(rune)}
+	},
+)
+
+var class = skipper.Lexeme(
+	c.Transform(
+		c.SeqOf(
+			c.SeqIgnore(c.Expect('[')),
+			c.Optional(c.Expect('^')),
+			c.ZeroOrMore(classRangeSyntax),
+			c.SeqIgnore(c.Expect(']')),
+		),
+		func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			class := c.CharClass{Negated: seq[0] != nil}
+			for _, r := range seq[1].([]interface{}) {
+				class.Ranges = append(class.Ranges, r.(c.RuneRange))
+			}
+
+			return classBuilder(class)
+		},
+	),
+)
+
+// classBuilder builds an ExpectAny for simple sets of runes and an ExpectClass otherwise
+func classBuilder(class c.CharClass) builder {
+	runes := []rune{}
+	for _, r := range class.Ranges {
+		if r.Lo == r.Hi {
+			runes = append(runes, r.Lo)
+		}
+	}
+
+	return func(l *loader) c.Parser {
+		if !class.Negated && len(runes) == len(class.Ranges) {
+			return c.ExpectAny(runes)
+		}
+
+		return c.ExpectClass(class)
+	}
+}
+
+var literal = skipper.Lexeme(
+	c.Transform(
+		c.QuotedString(pegQuotes),
+		func(i interface{}) interface{} {
+			text := []rune(i.(string))
+			return builder(func(l *loader) c.Parser {
+				return c.ExpectString(text)
+			})
+		},
+	),
+)
+
+// expression is recursive through parentheses, it's assigned in init to break
+// the initialization cycle
+var expression c.Parser
+
+var expressionRef = c.Lazy(func() c.Parser {
+	return expression
+})
+
+var primary = c.AnyOf(
+	c.Transform(
+		c.SeqOf(identifier, c.SeqIgnore(c.Not(leftArrow))),
+		func(i interface{}) interface{} {
+			name := i.([]interface{})[0].(string)
+			return builder(func(l *loader) c.Parser {
+				return l.reference(name)
+			})
+		},
+	),
+	c.Transform(
+		c.SeqOf(c.SeqIgnore(skipper.Symbol("(")), expressionRef, c.SeqIgnore(skipper.Symbol(")"))),
+		func(i interface{}) interface{} {
+			return i.([]interface{})[0]
+		},
+	),
+	literal,
+	class,
+	c.Transform(skipper.Symbol("."), func(i interface{}) interface{} {
+		return builder(func(l *loader) c.Parser {
+			return c.Any
+		})
+	}),
+)
+
+var suffix = c.Transform(
+	c.SeqOf(primary, c.Optional(c.AnyOf(skipper.Symbol("?"), skipper.Symbol("*"), skipper.Symbol("+")))),
+	func(i interface{}) interface{} {
+		seq := i.([]interface{})
+		inner := seq[0].(builder)
+		if seq[1] == nil {
+			return inner
+		}
+
+		repeat := map[string]func(c.Parser) c.Parser{"?": c.Optional, "*": c.ZeroOrMore, "+": c.OneOrMore}[seq[1].(string)]
+		return builder(func(l *loader) c.Parser {
+			return repeat(inner(l))
+		})
+	},
+)
+
+// prefixed results in a prefixedBuilder, lookaheads are marked so that
+// sequences can ignore their results
+var prefixed = c.Transform(
+	c.SeqOf(c.Optional(c.AnyOf(skipper.Symbol("&"), skipper.Symbol("!"))), suffix),
+	func(i interface{}) interface{} {
+		seq := i.([]interface{})
+		inner := seq[1].(builder)
+
+		switch seq[0] {
+		case "&":
+			return prefixedBuilder{true, func(l *loader) c.Parser { return c.Lookahead(inner(l)) }}
+		case "!":
+			return prefixedBuilder{true, func(l *loader) c.Parser { return c.Not(inner(l)) }}
+		}
+
+		return prefixedBuilder{false, inner}
+	},
+)
+
+type prefixedBuilder struct {
+	lookahead bool
+	build     builder
+}
+
+var sequence = c.Transform(
+	c.ZeroOrMore(prefixed),
+	func(i interface{}) interface{} {
+		items := []prefixedBuilder{}
+		values := 0
+		for _, item := range i.([]interface{}) {
+			items = append(items, item.(prefixedBuilder))
+			if !item.(prefixedBuilder).lookahead {
+				values++
+			}
+		}
+
+		return builder(func(l *loader) c.Parser {
+			if len(items) == 1 {
+				return items[0].build(l)
+			}
+
+			parsers := []c.Parser{}
+			for _, item := range items {
+				if item.lookahead {
+					parsers = append(parsers, c.SeqIgnore(item.build(l)))
+				} else {
+					parsers = append(parsers, item.build(l))
+				}
+			}
+
+			if values != 1 {
+				return c.SeqOf(parsers...)
+			}
+
+			return c.Unwrap(c.SeqOf(parsers...))
+		})
+	},
+)
+
+func init() {
+	expression = c.Named("Expression",
+		c.Transform(
+			c.SeqOf(sequence, c.ZeroOrMore(c.SeqOf(c.SeqIgnore(skipper.Symbol("/")), sequence))),
+			func(i interface{}) interface{} {
+				seq := i.([]interface{})
+				alternatives := []builder{seq[0].(builder)}
+				for _, alternative := range seq[1].([]interface{}) {
+					alternatives = append(alternatives, alternative.([]interface{})[0].(builder))
+				}
+
+				if len(alternatives) == 1 {
+					return alternatives[0]
+				}
+
+				return builder(func(l *loader) c.Parser {
+					parsers := []c.Parser{}
+					for _, alternative := range alternatives {
+						parsers = append(parsers, alternative(l))
+					}
+
+					return c.AnyOf(parsers...)
+				})
+			},
+		),
+	)
+}
+
+var definitionSyntax = c.Named("Definition",
+	c.Transform(
+		c.SeqOf(identifier, c.SeqIgnore(leftArrow), expressionRef),
+		func(i interface{}) interface{} {
+			seq := i.([]interface{})
+			return &definition{seq[0].(string), seq[1].(builder)}
+		},
+	),
+)
+
+var grammarSyntax = c.Named("Grammar",
+	c.Transform(
+		c.SeqOf(c.SeqIgnore(skipper.Trivia()), c.RepeatUntil(definitionSyntax, c.EOF)),
+		func(i interface{}) interface{} {
+			definitions := []*definition{}
+			for _, def := range i.([]interface{})[0].([]interface{}) {
+				definitions = append(definitions, def.(*definition))
+			}
+
+			return definitions
+		},
+	),
+)
@@ -0,0 +1,299 @@
+package combinators
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Profiler attributes the time and the work of parses to the Named rules of a
+// grammar, see WithProfiler. Profiles are read with Rules or exported with
+// WriteProfile for "go tool pprof", where the call stacks are the ones of the
+// rules. Compiled Programs are interpreted to be profiled and the timing
+// includes the overhead of the profiler. A Profiler must not be shared by
+// concurrent parses.
+type Profiler struct {
+	rules   map[Parser]*RuleProfile
+	order   []Parser
+	ids     map[Parser]int
+	samples map[string]*profileSample
+	keys    []string
+
+	stack  []*profileFrame
+	active map[Parser]int
+	// duration is the time of the outermost invocations
+	duration time.Duration
+
+	// session is the parse of the offsets in seen
+	session *parseSession
+	seen    map[profileKey]bool
+
+	// now is the clock of the profiler, replaced by the tests
+	now func() time.Time
+}
+
+// RuleProfile is the work done by a Named rule across the profiled parses
+type RuleProfile struct {
+	Name string
+
+	// Calls is the number of invocations of the rule
+	Calls int
+	// Backtracks is the number of failed invocations, whose work is discarded
+	// by the parsers trying something else
+	Backtracks int
+	// Reparses is the number of invocations at an offset where the rule was
+	// already invoked in the same parse, that Memoize would spare
+	Reparses int
+
+	// Time is the time spent in the rule, the nested invocations of recursive
+	// rules are counted once
+	Time time.Duration
+	// SelfTime is the time spent in the rule but not in the rules it invokes
+	SelfTime time.Duration
+}
+
+type profileKey struct {
+	rule   Parser
+	offset int
+}
+
+type profileFrame struct {
+	key      string
+	location []int
+	children time.Duration
+}
+
+// profileSample is the work done by the rule on top of a stack of rules
+type profileSample struct {
+	location   []int
+	calls      int
+	backtracks int
+	reparses   int
+	time       time.Duration
+}
+
+// NewProfiler creates an empty Profiler
+func NewProfiler() *Profiler {
+	p := &Profiler{now: time.Now}
+	p.Reset()
+	return p
+}
+
+// WithProfiler profiles the parse with the given Profiler
+func WithProfiler(profiler *Profiler) ParseOption {
+	return func(session *parseSession) {
+		session.profiler = profiler
+	}
+}
+
+// Reset discards everything recorded so far
+func (p *Profiler) Reset() {
+	p.rules = map[Parser]*RuleProfile{}
+	p.order = nil
+	p.ids = map[Parser]int{}
+	p.samples = map[string]*profileSample{}
+	p.keys = nil
+
+	p.stack = nil
+	p.active = map[Parser]int{}
+	p.duration = 0
+	p.session = nil
+	p.seen = nil
+}
+
+// Rules returns the profiles of the invoked rules, in order of first invocation
+func (p *Profiler) Rules() []RuleProfile {
+	rules := []RuleProfile{}
+	for _, rule := range p.order {
+		rules = append(rules, *p.rules[rule])
+	}
+
+	return rules
+}
+
+func (p *Profiler) record(session *parseSession, rule Parser, name string, state ParserState, fn FuncParser) (*ParserResult, error) {
+	if p.session != session {
+		// a parse aborted by a panic can leave frames behind
+		p.session = session
+		p.seen = map[profileKey]bool{}
+		p.stack = nil
+		p.active = map[Parser]int{}
+	}
+
+	profile, ok := p.rules[rule]
+	if !ok {
+		profile = &RuleProfile{Name: name}
+		p.rules[rule] = profile
+		p.order = append(p.order, rule)
+		p.ids[rule] = len(p.order)
+	}
+
+	offset := 0
+	if locator, ok := state.(Locator); ok {
+		offset = locator.Offset()
+	}
+
+	reparse := p.seen[profileKey{rule, offset}]
+	p.seen[profileKey{rule, offset}] = true
+
+	// the locations of pprof samples go from the innermost frame outwards
+	frame := &profileFrame{key: strconv.Itoa(p.ids[rule]), location: []int{p.ids[rule]}}
+	if len(p.stack) > 0 {
+		parent := p.stack[len(p.stack)-1]
+		frame.key = parent.key + "," + frame.key
+		frame.location = append(frame.location, parent.location...)
+	}
+
+	p.stack = append(p.stack, frame)
+	p.active[rule]++
+
+	start := p.now()
+	pr, err := fn(state)
+	elapsed := p.now().Sub(start)
+
+	p.stack = p.stack[:len(p.stack)-1]
+	p.active[rule]--
+
+	self := elapsed - frame.children
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].children += elapsed
+	} else {
+		p.duration += elapsed
+	}
+
+	profile.Calls++
+	profile.SelfTime += self
+	if p.active[rule] == 0 {
+		profile.Time += elapsed
+	}
+
+	sample, ok := p.samples[frame.key]
+	if !ok {
+		sample = &profileSample{location: frame.location}
+		p.samples[frame.key] = sample
+		p.keys = append(p.keys, frame.key)
+	}
+	sample.calls++
+	sample.time += self
+
+	if err != nil {
+		profile.Backtracks++
+		sample.backtracks++
+	}
+	if reparse {
+		profile.Reparses++
+		sample.reparses++
+	}
+
+	return pr, err
+}
+
+// WriteProfile writes the profile in the gzipped protocol buffer format of
+// pprof, with the sample types "calls", "backtracks", "reparses" and "time"
+// (the default one) of the rules on top of each stack of rules, like
+//
+//	go tool pprof -top -sample_index=reparses grammar.pprof
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	table := &protoStrings{index: map[string]int{}}
+	table.add("")
+
+	var profile protoBuffer
+
+	for _, sampleType := range [][2]string{{"calls", "count"}, {"backtracks", "count"}, {"reparses", "count"}, {"time", "nanoseconds"}} {
+		var valueType protoBuffer
+		valueType.int(1, int64(table.add(sampleType[0])))
+		valueType.int(2, int64(table.add(sampleType[1])))
+		profile.message(1, &valueType)
+	}
+
+	for _, key := range p.keys {
+		s := p.samples[key]
+
+		var sample protoBuffer
+		sample.packed(1, s.location)
+		sample.packed(2, []int{s.calls, s.backtracks, s.reparses, int(s.time)})
+		profile.message(2, &sample)
+	}
+
+	for i, rule := range p.order {
+		id := int64(i + 1)
+
+		var line protoBuffer
+		line.int(1, id)
+
+		var location protoBuffer
+		location.int(1, id)
+		location.message(4, &line)
+		profile.message(4, &location)
+
+		var function protoBuffer
+		function.int(1, id)
+		function.int(2, int64(table.add(p.rules[rule].Name)))
+		profile.message(5, &function)
+	}
+
+	for _, s := range table.table {
+		profile.bytes(6, []byte(s))
+	}
+	profile.int(10, int64(p.duration))
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.data); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// protoStrings is the string table of a pprof profile
+type protoStrings struct {
+	table []string
+	index map[string]int
+}
+
+func (s *protoStrings) add(str string) int {
+	if i, ok := s.index[str]; ok {
+		return i
+	}
+
+	s.index[str] = len(s.table)
+	s.table = append(s.table, str)
+	return len(s.table) - 1
+}
+
+// protoBuffer encodes the few protocol buffer wire types used by pprof
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		b.data = append(b.data, byte(v)|0x80)
+		v >>= 7
+	}
+	b.data = append(b.data, byte(v))
+}
+
+func (b *protoBuffer) int(field int, v int64) {
+	b.varint(uint64(field) << 3)
+	b.varint(uint64(v))
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) packed(field int, values []int) {
+	var inner protoBuffer
+	for _, v := range values {
+		inner.varint(uint64(v))
+	}
+	b.bytes(field, inner.data)
+}
+
+func (b *protoBuffer) message(field int, inner *protoBuffer) {
+	b.bytes(field, inner.data)
+}
@@ -0,0 +1,76 @@
+package combinators
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestProfiler creates a Profiler whose clock advances by a millisecond each time it's read
+func newTestProfiler() *Profiler {
+	p := NewProfiler()
+	clock := time.Time{}
+	p.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+
+	return p
+}
+
+func TestProfiler(t *testing.T) {
+	a := Named("A", Expect('a'))
+	parser := Named("Start", AnyOf(SeqOf(a, Expect('x')), SeqOf(a, Expect('y'))))
+
+	expected := []RuleProfile{
+		{Name: "Start", Calls: 2, Backtracks: 1, Time: 10 * time.Millisecond, SelfTime: 6 * time.Millisecond},
+		{Name: "A", Calls: 4, Backtracks: 2, Reparses: 2, Time: 4 * time.Millisecond, SelfTime: 4 * time.Millisecond},
+	}
+
+	for _, p := range []Parser{parser, Compile(parser)} {
+		profiler := newTestProfiler()
+		for _, input := range []string{"ay", "b"} {
+			ParseRuneReader(p, strings.NewReader(input), WithProfiler(profiler))
+		}
+		assert.Equal(t, expected, profiler.Rules())
+
+		profiler.Reset()
+		assert.Equal(t, []RuleProfile{}, profiler.Rules())
+	}
+
+	{
+		// recursive rules are timed once
+		var nested Parser
+		nested = Named("Nested", SeqOf(Expect('('), Optional(Lazy(func() Parser { return nested })), Expect(')')))
+
+		profiler := newTestProfiler()
+		_, err := ParseRuneReader(nested, strings.NewReader("(())"), WithProfiler(profiler))
+		assert.Nil(t, err)
+		assert.Equal(t, []RuleProfile{
+			{Name: "Nested", Calls: 3, Backtracks: 1, Time: 5 * time.Millisecond, SelfTime: 5 * time.Millisecond},
+		}, profiler.Rules())
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	profiler := newTestProfiler()
+	_, err := ParseRuneReader(expr, strings.NewReader("(1+2)*3"), WithProfiler(profiler))
+	assert.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, profiler.WriteProfile(buf))
+
+	gz, err := gzip.NewReader(buf)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(gz)
+	assert.Nil(t, err)
+
+	for _, s := range []string{"calls", "backtracks", "reparses", "nanoseconds", "Sum", "Product", "Value", "Number"} {
+		assert.Contains(t, string(data), s)
+	}
+}
@@ -0,0 +1,377 @@
+package combinators
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// QuoteStyle describes the syntax of a quoted string literal and how its
+// escape sequences are decoded
+type QuoteStyle struct {
+	// Name is used in error messages, for example "JSON string"
+	Name string
+	// Delimiters are the possible opening quotes, a literal is closed by the same quote that opened it
+	Delimiters []string
+
+	// Backslash enables escape sequences, when false every rune is taken literally
+	Backslash bool
+	// SimpleEscapes maps the rune following a backslash to its decoded value, for example 'n' to '\n'
+	SimpleEscapes map[rune]rune
+	// HexEscapes enables "\xHH"
+	HexEscapes bool
+	// UnicodeEscapes enables "\uXXXX"
+	UnicodeEscapes bool
+	// LongUnicodeEscapes enables "\UXXXXXXXX"
+	LongUnicodeEscapes bool
+	// OctalEscapes enables "\NNN" with up to three octal digits
+	OctalEscapes bool
+	// ByteEscapes makes hex and octal escapes produce raw bytes instead of
+	// code points (as in Go), octal escapes then require exactly three digits
+	ByteEscapes bool
+	// SurrogatePairs combines "\ud83d\ude00" in a single code point, when false surrogate halves are invalid
+	SurrogatePairs bool
+	// LineContinuation drops a backslash followed by a newline
+	LineContinuation bool
+	// KeepUnknownEscapes keeps unknown escape sequences verbatim instead of reporting them
+	KeepUnknownEscapes bool
+
+	// Multiline allows newlines inside the literal
+	Multiline bool
+	// NoControlCharacters rejects unescaped runes below U+0020
+	NoControlCharacters bool
+	// StripCarriageReturns drops every '\r' from the literal
+	StripCarriageReturns bool
+}
+
+// JSONQuotes is the style of JSON strings (RFC 8259)
+var JSONQuotes = QuoteStyle{
+	Name:       "JSON string",
+	Delimiters: []string{`"`},
+	Backslash:  true,
+	SimpleEscapes: map[rune]rune{
+		'"': '"', '\\': '\\', '/': '/',
+		'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t',
+	},
+	UnicodeEscapes:      true,
+	SurrogatePairs:      true,
+	NoControlCharacters: true,
+}
+
+// GoQuotes is the style of Go interpreted string literals
+var GoQuotes = QuoteStyle{
+	Name:       "Go string",
+	Delimiters: []string{`"`},
+	Backslash:  true,
+	SimpleEscapes: map[rune]rune{
+		'"': '"', '\\': '\\',
+		'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	},
+	HexEscapes:         true,
+	UnicodeEscapes:     true,
+	LongUnicodeEscapes: true,
+	OctalEscapes:       true,
+	ByteEscapes:        true,
+}
+
+// GoRawQuotes is the style of Go raw string literals
+var GoRawQuotes = QuoteStyle{
+	Name:                 "Go raw string",
+	Delimiters:           []string{"`"},
+	Multiline:            true,
+	StripCarriageReturns: true,
+}
+
+// ShellSingleQuotes is the style of single quoted shell strings, where no escape is possible
+var ShellSingleQuotes = QuoteStyle{
+	Name:       "single quoted string",
+	Delimiters: []string{`'`},
+	Multiline:  true,
+}
+
+// PythonTripleQuotes is the style of Python triple quoted strings, named
+// unicode escapes ("\N{...}") are not decoded and kept verbatim
+var PythonTripleQuotes = QuoteStyle{
+	Name:       "triple quoted string",
+	Delimiters: []string{`"""`, `'''`},
+	Backslash:  true,
+	SimpleEscapes: map[rune]rune{
+		'"': '"', '\'': '\'', '\\': '\\',
+		'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	},
+	HexEscapes:         true,
+	UnicodeEscapes:     true,
+	LongUnicodeEscapes: true,
+	OctalEscapes:       true,
+	LineContinuation:   true,
+	KeepUnknownEscapes: true,
+	Multiline:          true,
+}
+
+// JSONString parses a JSON string and returns its decoded value
+var JSONString = QuotedString(JSONQuotes)
+
+// GoString parses a Go interpreted string literal and returns its decoded value
+var GoString = QuotedString(GoQuotes)
+
+// GoRawString parses a Go raw string literal and returns its value
+var GoRawString = QuotedString(GoRawQuotes)
+
+// ShellString parses a single quoted shell string and returns its value
+var ShellString = QuotedString(ShellSingleQuotes)
+
+// PythonLongString parses a Python triple quoted string and returns its decoded value
+var PythonLongString = QuotedString(PythonTripleQuotes)
+
+// QuotedString creates a Parser for string literals of the given style, the
+// Result is the decoded string. Malformed escape sequences are reported as
+// ParseErrors located at their backslash.
+func QuotedString(style QuoteStyle) Parser {
+	return newCombinator(Description{Kind: KindQuotedString, Text: style.Name}, func(state ParserState) (*ParserResult, error) {
+		var delimiter string
+		var currentState ParserState
+
+		for _, d := range style.Delimiters {
+			if after, ok := matchPrefix(state, d); ok {
+				delimiter, currentState = d, after
+				break
+			}
+		}
+
+		if currentState == nil {
+			if state.CurrentRune() == 0 {
+				return Fail(state, fmt.Errorf(`Stream ended, expected %s`, style.Name))
+			}
+
+			return Fail(state, fmt.Errorf(`Expected %s`, style.Name))
+		}
+
+		buf := &bytes.Buffer{}
+
+		for {
+			r := currentState.CurrentRune()
+
+			if r == 0 {
+				return Fail(currentState, ErrorAt(currentState, fmt.Errorf(`Stream ended, expected closing %s of %s`, delimiter, style.Name)))
+			}
+
+			if after, ok := matchPrefix(currentState, delimiter); ok {
+				return Success(after, buf.String())
+			}
+
+			if r == '\\' && style.Backslash {
+				next, err := style.decodeEscape(currentState, buf)
+				if err != nil {
+					return Fail(currentState, ErrorAt(currentState, err))
+				}
+
+				currentState = next
+				continue
+			}
+
+			if r == '\n' && !style.Multiline {
+				return Fail(currentState, ErrorAt(currentState, fmt.Errorf(`Unexpected newline in %s`, style.Name)))
+			}
+
+			if r < 0x20 && style.NoControlCharacters {
+				return Fail(currentState, ErrorAt(currentState, fmt.Errorf(`Invalid control character %U in %s`, r, style.Name)))
+			}
+
+			if r != '\r' || !style.StripCarriageReturns {
+				buf.WriteRune(r)
+			}
+
+			currentState = currentState.Remaining()
+		}
+	})
+}
+
+// decodeEscape decodes the escape sequence starting at the backslash under
+// "state", writes its value to buf and returns the state after the sequence
+func (style *QuoteStyle) decodeEscape(state ParserState, buf *bytes.Buffer) (ParserState, error) {
+	currentState := state.Remaining()
+	r := currentState.CurrentRune()
+
+	if r == 0 {
+		return nil, fmt.Errorf(`Stream ended, expected escape sequence`)
+	}
+
+	if decoded, ok := style.SimpleEscapes[r]; ok {
+		buf.WriteRune(decoded)
+		return currentState.Remaining(), nil
+	}
+
+	switch {
+	case r == '\n' && style.LineContinuation:
+		return currentState.Remaining(), nil
+
+	case r == 'x' && style.HexEscapes:
+		value, next, err := readDigits(currentState.Remaining(), 16, 2, 2)
+		if err != nil {
+			return nil, invalidEscape(`\x`, err)
+		}
+
+		if style.ByteEscapes {
+			buf.WriteByte(byte(value))
+		} else {
+			buf.WriteRune(rune(value))
+		}
+		return next, nil
+
+	case r == 'u' && style.UnicodeEscapes:
+		value, next, err := readDigits(currentState.Remaining(), 16, 4, 4)
+		if err != nil {
+			return nil, invalidEscape(`\u`, err)
+		}
+
+		if !utf16.IsSurrogate(rune(value)) {
+			buf.WriteRune(rune(value))
+			return next, nil
+		}
+
+		if !style.SurrogatePairs {
+			return nil, fmt.Errorf(`Invalid escape sequence "\u%04x", surrogate halves are not valid code points`, value)
+		}
+
+		if low, after, ok := readLowSurrogate(next); ok {
+			if decoded := utf16.DecodeRune(rune(value), low); decoded != utf8.RuneError {
+				buf.WriteRune(decoded)
+				return after, nil
+			}
+		}
+
+		return nil, fmt.Errorf(`Invalid escape sequence "\u%04x", unpaired surrogate`, value)
+
+	case r == 'U' && style.LongUnicodeEscapes:
+		value, next, err := readDigits(currentState.Remaining(), 16, 8, 8)
+		if err != nil {
+			return nil, invalidEscape(`\U`, err)
+		}
+
+		if value > utf8.MaxRune || utf16.IsSurrogate(rune(value)) {
+			return nil, fmt.Errorf(`Invalid escape sequence "\U%08x", not a valid code point`, value)
+		}
+
+		buf.WriteRune(rune(value))
+		return next, nil
+
+	case '0' <= r && r <= '7' && style.OctalEscapes:
+		minDigits := 1
+		if style.ByteEscapes {
+			minDigits = 3
+		}
+
+		value, next, err := readDigits(currentState, 8, minDigits, 3)
+		if err != nil {
+			return nil, invalidEscape(`\`, err)
+		}
+
+		if style.ByteEscapes {
+			if value > 255 {
+				return nil, fmt.Errorf(`Invalid escape sequence "\%o", octal value over 255`, value)
+			}
+
+			buf.WriteByte(byte(value))
+		} else {
+			buf.WriteRune(rune(value))
+		}
+		return next, nil
+	}
+
+	if style.KeepUnknownEscapes {
+		buf.WriteRune('\\')
+		return currentState, nil
+	}
+
+	return nil, fmt.Errorf(`Invalid escape sequence "\%c"`, r)
+}
+
+// readDigits reads from "minDigits" to "maxDigits" digits in the given base
+func readDigits(state ParserState, base, minDigits, maxDigits int) (int, ParserState, *digitsError) {
+	value := 0
+	digits := []rune{}
+	currentState := state
+
+	for len(digits) < maxDigits {
+		r := currentState.CurrentRune()
+		d := digitValue(r)
+
+		if d < 0 || d >= base {
+			if len(digits) >= minDigits {
+				break
+			}
+
+			return 0, nil, &digitsError{string(digits), r}
+		}
+
+		value = value*base + d
+		digits = append(digits, r)
+		currentState = currentState.Remaining()
+	}
+
+	return value, currentState, nil
+}
+
+// readLowSurrogate reads a "\uXXXX" escape holding the second half of a surrogate pair
+func readLowSurrogate(state ParserState) (rune, ParserState, bool) {
+	next, ok := matchPrefix(state, `\u`)
+	if !ok {
+		return 0, nil, false
+	}
+
+	value, after, err := readDigits(next, 16, 4, 4)
+	if err != nil || value < 0xDC00 || value > 0xDFFF {
+		return 0, nil, false
+	}
+
+	return rune(value), after, true
+}
+
+// digitsError is an escape sequence with too few digits, r is the rune after
+// them or 0 at the end of stream
+type digitsError struct {
+	digits string
+	r      rune
+}
+
+func invalidEscape(prefix string, de *digitsError) error {
+	switch {
+	case de.r == 0:
+		return fmt.Errorf(`Invalid escape sequence "%s%s" followed by end of stream`, prefix, de.digits)
+	case strings.ContainsRune("\"'`", de.r):
+		// the quote most likely ends the string, it isn't part of the escape
+		return fmt.Errorf(`Invalid escape sequence "%s%s", too few digits`, prefix, de.digits)
+	}
+
+	return fmt.Errorf(`Invalid escape sequence "%s%s%c"`, prefix, de.digits, de.r)
+}
+
+func digitValue(r rune) int {
+	switch {
+	case '0' <= r && r <= '9':
+		return int(r - '0')
+	case 'a' <= r && r <= 'f':
+		return int(r-'a') + 10
+	case 'A' <= r && r <= 'F':
+		return int(r-'A') + 10
+	}
+
+	return -1
+}
+
+// matchPrefix returns the state after "prefix" if the input continues with it
+func matchPrefix(state ParserState, prefix string) (ParserState, bool) {
+	currentState := state
+
+	for _, expected := range prefix {
+		if currentState.CurrentRune() != expected {
+			return nil, false
+		}
+
+		currentState = currentState.Remaining()
+	}
+
+	return currentState, true
+}
@@ -0,0 +1,99 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONString(t *testing.T) {
+	{
+		r, _ := ParseRuneReader(JSONString, strings.NewReader(`"a\"b\\c\/\n\t"`))
+		assert.Equal(t, "a\"b\\c/\n\t", r)
+	}
+	{
+		r, _ := ParseRuneReader(JSONString, strings.NewReader(`"café"`))
+		assert.Equal(t, "café", r)
+	}
+	{
+		r, _ := ParseRuneReader(JSONString, strings.NewReader(`"\ud83d\ude00"`))
+		assert.Equal(t, "\U0001F600", r)
+	}
+	{
+		_, err := ParseRuneReader(JSONString, strings.NewReader(`"ab\qc"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\q" at 1:4`)
+	}
+	{
+		_, err := ParseRuneReader(JSONString, strings.NewReader(`"ab\u12g4"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\u12g" at 1:4`)
+	}
+	{
+		_, err := ParseRuneReader(JSONString, strings.NewReader(`"\ud83dx"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\ud83d", unpaired surrogate at 1:2`)
+	}
+	{
+		_, err := ParseRuneReader(JSONString, strings.NewReader("\"a\nb\""))
+		assert.EqualError(t, err, `Unexpected newline in JSON string at 1:3`)
+	}
+	{
+		_, err := ParseRuneReader(JSONString, strings.NewReader(`"abc`))
+		assert.EqualError(t, err, `Stream ended, expected closing " of JSON string at 1:5`)
+	}
+	{
+		_, err := ParseRuneReader(JSONString, strings.NewReader(`abc`))
+		assert.EqualError(t, err, `Expected JSON string`)
+	}
+}
+
+func TestGoString(t *testing.T) {
+	{
+		r, _ := ParseRuneReader(GoString, strings.NewReader(`"\x41\101é\U0001F600\a"`))
+		assert.Equal(t, "AAé\U0001F600\a", r)
+	}
+	{
+		r, _ := ParseRuneReader(GoString, strings.NewReader(`"\xff"`))
+		assert.Equal(t, "\xff", r)
+	}
+	{
+		_, err := ParseRuneReader(GoString, strings.NewReader(`"\'"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\'" at 1:2`)
+	}
+	{
+		_, err := ParseRuneReader(GoString, strings.NewReader(`"\12"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\12", too few digits at 1:2`)
+	}
+	{
+		_, err := ParseRuneReader(GoString, strings.NewReader(`"\400"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\400", octal value over 255 at 1:2`)
+	}
+	{
+		_, err := ParseRuneReader(GoString, strings.NewReader(`"\ud800"`))
+		assert.EqualError(t, err, `Invalid escape sequence "\ud800", surrogate halves are not valid code points at 1:2`)
+	}
+	{
+		r, _ := ParseRuneReader(GoRawString, strings.NewReader("`a\\n\r\nb`"))
+		assert.Equal(t, "a\\n\nb", r)
+	}
+}
+
+func TestShellAndPythonStrings(t *testing.T) {
+	{
+		r, _ := ParseRuneReader(ShellString, strings.NewReader(`'a\nb' rest`))
+		assert.Equal(t, `a\nb`, r)
+	}
+	{
+		r, _ := ParseRuneReader(PythonLongString, strings.NewReader(`"""a "quoted" ""word""
+\x41\q\
+b"""`))
+		assert.Equal(t, "a \"quoted\" \"\"word\"\"\nA\\qb", r)
+	}
+	{
+		r, _ := ParseRuneReader(PythonLongString, strings.NewReader(`'''it's'''`))
+		assert.Equal(t, "it's", r)
+	}
+	{
+		_, err := ParseRuneReader(PythonLongString, strings.NewReader("'''\n\n  \\x4'''"))
+		assert.EqualError(t, err, `Invalid escape sequence "\x4", too few digits at 3:3`)
+	}
+}
@@ -0,0 +1,378 @@
+// Package syntax builds bidirectional grammars: a Syntax is a Parser that can
+// also print the values it parses back to canonical text, so a single
+// description of a language replaces a parser and a printer kept in sync by
+// hand. Syntaxes are built like parsers
+//
+//	pair := syntax.Seq(word, syntax.Skip(spaces, " "), syntax.Literal("="), syntax.Skip(spaces, " "), word)
+//
+// and results are converted to values with invertible Isos instead of plain
+// functions. The parse side is built with the combinators of this module, so
+// a Syntax can be traced, analyzed, exported or compiled like any Parser.
+//
+// Values are the ones of the corresponding combinators: Seq results in a
+// []interface{} of the values of its items that are not Literals or Skips, or
+// in the value of its only item, and Seqs of only Literals and Skips are
+// ignored too. Repetitions result in a []interface{} and Optional in nil when
+// missing. Printing checks values have this shape and fails
+// otherwise, alternatives print the first alternative that accepts a value.
+package syntax
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	c "github.com/aziis98/parser-combinators"
+)
+
+// Syntax is a Parser that can print its values back to text
+type Syntax interface {
+	c.Parser
+	// Print returns the canonical text of a value
+	Print(value interface{}) (string, error)
+}
+
+// syntax joins the parser and the printer of a Syntax
+type syntax struct {
+	parser c.Parser
+	print  func(value interface{}) (string, error)
+	// ignored marks Literals and Skips, their values are not part of sequences
+	ignored bool
+}
+
+func (s *syntax) Apply(state c.ParserState) (*c.ParserResult, error) {
+	return s.parser.Apply(state)
+}
+
+func (s *syntax) Print(value interface{}) (string, error) {
+	return s.print(value)
+}
+
+// Describe describes the parser of the Syntax, see combinators.Describable
+func (s *syntax) Describe() c.Description {
+	return c.Describe(s.parser)
+}
+
+// parserOf returns the parser of a Syntax built by this package
+func parserOf(s Syntax) c.Parser {
+	if s, ok := s.(*syntax); ok {
+		return s.parser
+	}
+
+	return s
+}
+
+func isIgnored(s Syntax) bool {
+	inner, ok := s.(*syntax)
+	return ok && inner.ignored
+}
+
+// Iso is an invertible transformation, Apply converts the results of a
+// parser to values and Unapply converts them back. Unapply fails for values
+// that Apply can't return, like the values of other alternatives.
+type Iso struct {
+	Apply   func(interface{}) (interface{}, error)
+	Unapply func(interface{}) (interface{}, error)
+}
+
+// Inverse returns the Iso with Apply and Unapply swapped
+func Inverse(iso Iso) Iso {
+	return Iso{iso.Unapply, iso.Apply}
+}
+
+// Transform converts the values of a Syntax with an Iso, when parsing the
+// errors of Apply are located at the start of the transformed text like the
+// ones of TransformErr
+func Transform(s Syntax, iso Iso) Syntax {
+	return &syntax{
+		parser: c.TransformErr(parserOf(s), iso.Apply),
+		print: func(value interface{}) (string, error) {
+			inner, err := iso.Unapply(value)
+			if err != nil {
+				return "", err
+			}
+
+			return s.Print(inner)
+		},
+	}
+}
+
+// Skip parses with any parser and prints the canonical text, like
+// Skip(c.ZeroOrMore(c.InlineSpace), " ") for the spaces between tokens.
+// Its value is not part of sequences and is ignored when printing.
+func Skip(parser c.Parser, canonical string) Syntax {
+	return &syntax{
+		parser: c.SeqIgnore(parser),
+		print: func(interface{}) (string, error) {
+			return canonical, nil
+		},
+		ignored: true,
+	}
+}
+
+// Literal parses and prints the given text, its value is not part of sequences
+func Literal(text string) Syntax {
+	return Skip(c.ExpectString([]rune(text)), text)
+}
+
+// Chars parses one or more runes satisfying the predicate, its value is the string of the runes
+func Chars(predicate func(rune) bool, description string) Syntax {
+	return &syntax{
+		parser: c.StringifyResult(c.OneOrMore(c.ExpectPredicate(predicate, description))),
+		print: func(value interface{}) (string, error) {
+			text, ok := value.(string)
+			if !ok || text == "" {
+				return "", fmt.Errorf(`Cannot print %#v as one or more %s`, value, description)
+			}
+
+			for _, r := range text {
+				if !predicate(r) {
+					return "", fmt.Errorf(`Cannot print %q as one or more %s`, text, description)
+				}
+			}
+
+			return text, nil
+		},
+	}
+}
+
+// Seq parses and prints its items in order
+func Seq(items ...Syntax) Syntax {
+	parsers := []c.Parser{}
+	values := 0
+	for _, item := range items {
+		parsers = append(parsers, parserOf(item))
+		if !isIgnored(item) {
+			values++
+		}
+	}
+
+	parser := c.SeqOf(parsers...)
+	switch values {
+	case 0:
+		parser = c.SeqIgnore(parser)
+	case 1:
+		parser = c.Unwrap(parser)
+	}
+
+	return &syntax{
+		parser: parser,
+		print: func(value interface{}) (string, error) {
+			var seq []interface{}
+			switch values {
+			case 0:
+			case 1:
+				seq = []interface{}{value}
+			default:
+				var ok bool
+				if seq, ok = value.([]interface{}); !ok || len(seq) != values {
+					return "", fmt.Errorf(`Cannot print %#v as a sequence of %d values`, value, values)
+				}
+			}
+
+			var sb strings.Builder
+			for _, item := range items {
+				var itemValue interface{}
+				if !isIgnored(item) {
+					itemValue, seq = seq[0], seq[1:]
+				}
+
+				text, err := item.Print(itemValue)
+				if err != nil {
+					return "", err
+				}
+
+				sb.WriteString(text)
+			}
+
+			return sb.String(), nil
+		},
+		ignored: values == 0,
+	}
+}
+
+// AnyOf parses with the first matching alternative and prints with the
+// first alternative that can print the value
+func AnyOf(alternatives ...Syntax) Syntax {
+	parsers := []c.Parser{}
+	for _, alternative := range alternatives {
+		parsers = append(parsers, parserOf(alternative))
+	}
+
+	return &syntax{
+		parser: c.AnyOf(parsers...),
+		print: func(value interface{}) (string, error) {
+			errs := []string{}
+			for _, alternative := range alternatives {
+				text, err := alternative.Print(value)
+				if err == nil {
+					return text, nil
+				}
+
+				errs = append(errs, err.Error())
+			}
+
+			return "", fmt.Errorf(`Cannot print %#v with any alternative: %s`, value, strings.Join(errs, "; "))
+		},
+	}
+}
+
+// repeat prints the items of a []interface{} with a separator between them
+func repeat(item, separator Syntax, min int, value interface{}) (string, error) {
+	items, ok := value.([]interface{})
+	if !ok || len(items) < min {
+		return "", fmt.Errorf(`Cannot print %#v as a list of at least %d values`, value, min)
+	}
+
+	var sb strings.Builder
+	for i, itemValue := range items {
+		if i > 0 && separator != nil {
+			text, err := separator.Print(nil)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(text)
+		}
+
+		text, err := item.Print(itemValue)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(text)
+	}
+
+	return sb.String(), nil
+}
+
+// ZeroOrMore parses and prints a []interface{} of items
+func ZeroOrMore(item Syntax) Syntax {
+	return &syntax{
+		parser: c.ZeroOrMore(parserOf(item)),
+		print: func(value interface{}) (string, error) {
+			return repeat(item, nil, 0, value)
+		},
+	}
+}
+
+// OneOrMore parses and prints a []interface{} of at least one item
+func OneOrMore(item Syntax) Syntax {
+	return &syntax{
+		parser: c.OneOrMore(parserOf(item)),
+		print: func(value interface{}) (string, error) {
+			return repeat(item, nil, 1, value)
+		},
+	}
+}
+
+// SepBy parses and prints a []interface{} of zero or more items with a
+// separator between them, the separator is usually a Literal or a Skip
+func SepBy(item, separator Syntax) Syntax {
+	parser := c.Transform(
+		c.Optional(c.SeqOf(parserOf(item), c.ZeroOrMore(c.Unwrap(c.SeqOf(c.SeqIgnore(parserOf(separator)), parserOf(item)))))),
+		func(i interface{}) interface{} {
+			if i == nil {
+				return []interface{}{}
+			}
+
+			seq := i.([]interface{})
+			return append([]interface{}{seq[0]}, seq[1].([]interface{})...)
+		},
+	)
+
+	return &syntax{
+		parser: parser,
+		print: func(value interface{}) (string, error) {
+			return repeat(item, separator, 0, value)
+		},
+	}
+}
+
+// Optional parses an item or nothing, resulting in nil, and prints nil as nothing
+func Optional(item Syntax) Syntax {
+	return &syntax{
+		parser: c.Optional(parserOf(item)),
+		print: func(value interface{}) (string, error) {
+			if value == nil {
+				return "", nil
+			}
+
+			return item.Print(value)
+		},
+	}
+}
+
+// Named gives a name to a grammar rule, see combinators.Named
+func Named(name string, s Syntax) Syntax {
+	parser := c.Named(name, parserOf(s))
+	if isIgnored(s) {
+		parser = c.SeqIgnore(parser)
+	}
+
+	return &syntax{
+		parser: parser,
+		print: func(value interface{}) (string, error) {
+			text, err := s.Print(value)
+			if err != nil {
+				return "", fmt.Errorf(`%s: %w`, name, err)
+			}
+
+			return text, nil
+		},
+		ignored: isIgnored(s),
+	}
+}
+
+// Label replaces the low level expectations of a Syntax in error messages, see combinators.Label
+func Label(s Syntax, label string) Syntax {
+	parser := c.Label(parserOf(s), label)
+	if isIgnored(s) {
+		parser = c.SeqIgnore(parser)
+	}
+
+	return &syntax{parser: parser, print: s.Print, ignored: isIgnored(s)}
+}
+
+// Lazy creates a Syntax that gets the actual one on first use, for recursive grammars
+func Lazy(get func() Syntax) Syntax {
+	return &syntax{
+		parser: c.Lazy(func() c.Parser {
+			return parserOf(get())
+		}),
+		print: func(value interface{}) (string, error) {
+			return get().Print(value)
+		},
+	}
+}
+
+// Whole returns a parser of the whole input with a Syntax, resulting in its
+// value or in nil for the Syntaxes ignored in sequences, like Literals
+func Whole(s Syntax) c.Parser {
+	if isIgnored(s) {
+		return c.Transform(c.SeqOf(parserOf(s), c.EOF), func(interface{}) interface{} {
+			return nil
+		})
+	}
+
+	return c.Unwrap(c.SeqOf(parserOf(s), c.SeqIgnore(c.EOF)))
+}
+
+// RoundTrip prints a value and parses the text back, requiring the whole
+// text to be consumed, and returns an error unless the parsed value is
+// deeply equal to the original one
+func RoundTrip(s Syntax, value interface{}, opts ...c.ParseOption) error {
+	text, err := s.Print(value)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := c.ParseRuneReader(Whole(s), strings.NewReader(text), opts...)
+	if err != nil {
+		return fmt.Errorf(`Cannot parse %q printed from %#v: %w`, text, value, err)
+	}
+
+	if !reflect.DeepEqual(value, parsed) {
+		return fmt.Errorf(`Round trip of %#v printed %q and parsed back %#v`, value, text, parsed)
+	}
+
+	return nil
+}
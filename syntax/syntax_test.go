@@ -0,0 +1,138 @@
+package syntax
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	c "github.com/aziis98/parser-combinators"
+	"github.com/stretchr/testify/assert"
+)
+
+var spaces = c.ZeroOrMore(c.Expect(' '))
+
+var number = Transform(Chars(unicode.IsDigit, "digit"), Iso{
+	Apply: func(i interface{}) (interface{}, error) {
+		return strconv.Atoi(i.(string))
+	},
+	Unapply: func(i interface{}) (interface{}, error) {
+		n, ok := i.(int)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf(`Not a natural number: %#v`, i)
+		}
+
+		return strconv.Itoa(n), nil
+	},
+})
+
+// value is a number or a list of values like "[1, [2, 3]]"
+var value Syntax
+
+func init() {
+	value = Named("Value", AnyOf(
+		number,
+		Seq(
+			Literal("["),
+			SepBy(Lazy(func() Syntax { return value }), Seq(Skip(spaces, ""), Literal(","), Skip(spaces, " "))),
+			Literal("]"),
+		),
+	))
+}
+
+func parse(s Syntax, input string) (interface{}, error) {
+	return c.ParseRuneReader(s, strings.NewReader(input))
+}
+
+func TestSyntax(t *testing.T) {
+	{
+		r, err := parse(value, "[1,2 ,  [3],[]]")
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{1, 2, []interface{}{3}, []interface{}{}}, r)
+
+		text, err := value.Print(r)
+		assert.Nil(t, err)
+		assert.Equal(t, "[1, 2, [3], []]", text)
+	}
+	{
+		pair := Seq(Chars(unicode.IsLetter, "letter"), Skip(spaces, " "), Literal("="), Skip(spaces, " "), Optional(number))
+
+		r, err := parse(pair, "x=1")
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"x", 1}, r)
+
+		text, err := pair.Print([]interface{}{"y", nil})
+		assert.Nil(t, err)
+		assert.Equal(t, "y = ", text)
+
+		_, err = pair.Print([]interface{}{"y"})
+		assert.EqualError(t, err, `Cannot print []interface {}{"y"} as a sequence of 2 values`)
+		_, err = pair.Print([]interface{}{"y1", 1})
+		assert.EqualError(t, err, `Cannot print "y1" as one or more letter`)
+	}
+	{
+		_, err := value.Print([]interface{}{1, -2})
+		assert.EqualError(t, err, `Value: Cannot print []interface {}{1, -2} with any alternative: `+
+			`Not a natural number: []interface {}{1, -2}; `+
+			`Value: Cannot print -2 with any alternative: Not a natural number: -2; Cannot print -2 as a list of at least 0 values`)
+	}
+	{
+		// sequences of ignored items are ignored
+		parens := Seq(Seq(Literal("("), Skip(spaces, "")), number, Named("Close", Literal(")")))
+		r, err := parse(parens, "(  12)")
+		assert.Nil(t, err)
+		assert.Equal(t, 12, r)
+
+		text, err := parens.Print(12)
+		assert.Nil(t, err)
+		assert.Equal(t, "(12)", text)
+	}
+	{
+		labeled := Seq(Label(Chars(unicode.IsLetter, "letter"), "name"), Label(Literal("="), "equals sign"), number)
+
+		_, err := parse(labeled, "1=2")
+		assert.EqualError(t, err, `Expected name at 1:1`)
+		_, err = parse(labeled, "x-2")
+		assert.EqualError(t, err, `Expected equals sign at 1:2`)
+
+		text, err := labeled.Print([]interface{}{"x", 2})
+		assert.Nil(t, err)
+		assert.Equal(t, "x=2", text)
+	}
+	{
+		assert.Equal(t, c.KindNamed, c.Describe(value).Kind)
+		assert.Empty(t, c.Analyze(value).Warnings)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, v := range []interface{}{
+		0,
+		[]interface{}{},
+		[]interface{}{1, []interface{}{2, []interface{}{}}, 3},
+	} {
+		assert.Nil(t, RoundTrip(value, v))
+	}
+
+	assert.EqualError(t, RoundTrip(value, "a"), `Value: Cannot print "a" with any alternative: Not a natural number: "a"; Cannot print "a" as a list of at least 0 values`)
+
+	// the printed text of a lossy Iso parses to another value
+	lossy := Transform(Chars(unicode.IsLetter, "letter"), Iso{
+		Apply: func(i interface{}) (interface{}, error) {
+			return strings.ToLower(i.(string)), nil
+		},
+		Unapply: func(i interface{}) (interface{}, error) {
+			return i, nil
+		},
+	})
+	assert.EqualError(t, RoundTrip(lossy, "Go"), `Round trip of "Go" printed "Go" and parsed back "go"`)
+
+	// Literals and sequences of them result in nil
+	assert.Nil(t, RoundTrip(Literal("x"), nil))
+	assert.Nil(t, RoundTrip(Seq(Literal("x"), Skip(c.InlineSpace, " ")), nil))
+	assert.EqualError(t, RoundTrip(Literal("x"), "x"), `Round trip of "x" printed "x" and parsed back <nil>`)
+
+	// the printed text must be parsed completely
+	assert.EqualError(t, RoundTrip(Seq(number, Skip(c.Optional(c.Expect(';')), ";;")), 1), `Cannot parse "1;;" printed from 1: Expected end of stream`)
+}
@@ -0,0 +1,326 @@
+package combinators
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Token is a piece of input recognized by a Tokenizer
+type Token struct {
+	Kind string
+	Text string
+
+	// Offset is the byte offset of the token in the input
+	Offset int
+	// Line and Column are one based, the column is counted in runes
+	Line   int
+	Column int
+}
+
+// Tokenizer splits an input in a sequence of tokens using literal and regexp
+// rules. At each position the longest match wins, when two rules match the
+// same length literals win over regexps and then the first defined rule wins.
+type Tokenizer struct {
+	rules []*tokenRule
+}
+
+type tokenRule struct {
+	kind    string
+	literal string
+	pattern *regexp.Regexp
+	skip    bool
+}
+
+func (rule *tokenRule) match(input string) int {
+	if rule.pattern == nil {
+		if strings.HasPrefix(input, rule.literal) {
+			return len(rule.literal)
+		}
+
+		return 0
+	}
+
+	loc := rule.pattern.FindStringIndex(input)
+	if loc == nil {
+		return 0
+	}
+
+	return loc[1]
+}
+
+// NewTokenizer creates a Tokenizer without rules
+func NewTokenizer() *Tokenizer {
+	return &Tokenizer{}
+}
+
+// Literal adds rules producing tokens of the given kind for each of the given strings
+func (t *Tokenizer) Literal(kind string, texts ...string) *Tokenizer {
+	for _, text := range texts {
+		t.rules = append(t.rules, &tokenRule{kind: kind, literal: text})
+	}
+
+	return t
+}
+
+// Regexp adds a rule producing tokens of the given kind for the text matched by "pattern"
+func (t *Tokenizer) Regexp(kind, pattern string) *Tokenizer {
+	t.rules = append(t.rules, &tokenRule{kind: kind, pattern: anchoredRegexp(pattern)})
+	return t
+}
+
+// Skip adds a rule for text matched by "pattern" that is dropped from the
+// output, like whitespace or comments
+func (t *Tokenizer) Skip(pattern string) *Tokenizer {
+	t.rules = append(t.rules, &tokenRule{pattern: anchoredRegexp(pattern), skip: true})
+	return t
+}
+
+func anchoredRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`\A(?:` + pattern + `)`)
+}
+
+// Tokenize reads all the input and splits it in tokens
+func (t *Tokenizer) Tokenize(r io.Reader) ([]Token, error) {
+	source, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.TokenizeString(string(source))
+}
+
+// TokenizeString splits the given string in tokens
+func (t *Tokenizer) TokenizeString(source string) ([]Token, error) {
+	tokens := []Token{}
+	offset, line, column := 0, 1, 1
+
+	for offset < len(source) {
+		var best *tokenRule
+		bestLength := 0
+
+		for _, rule := range t.rules {
+			length := rule.match(source[offset:])
+
+			if length > bestLength || (length == bestLength && length > 0 && best.pattern != nil && rule.pattern == nil) {
+				best, bestLength = rule, length
+			}
+		}
+
+		if best == nil {
+			r, _ := utf8.DecodeRuneInString(source[offset:])
+			loc := &fixedLocation{offset, line - 1, column}
+			return nil, &ParseError{Offset: offset, Err: fmt.Errorf(`Unexpected character "%c"`, r), locator: loc}
+		}
+
+		text := source[offset : offset+bestLength]
+		if !best.skip {
+			tokens = append(tokens, Token{best.kind, text, offset, line, column})
+		}
+
+		for _, r := range text {
+			if r == '\n' {
+				line, column = line+1, 1
+			} else {
+				column++
+			}
+		}
+
+		offset += bestLength
+	}
+
+	return tokens, nil
+}
+
+// Parse tokenizes all the input and parses the resulting tokens
+func (t *Tokenizer) Parse(parser Parser, r io.Reader, opts ...ParseOption) (interface{}, error) {
+	session := newParseSession(opts)
+
+	max := session.limits.MaxInputSize
+	if max > 0 {
+		r = io.LimitReader(r, int64(max)+1)
+	}
+
+	source, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if max > 0 && len(source) > max {
+		return nil, fmt.Errorf(`%w: more than %d bytes of input`, ErrLimitExceeded, max)
+	}
+
+	tokens, err := t.TokenizeString(string(source))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTokenState(parser, &TokenState{source: string(source), tokens: tokens}, session)
+}
+
+// fixedLocation is a Locator for an already known position
+type fixedLocation struct {
+	offset    int
+	line, col int
+}
+
+func (l *fixedLocation) Offset() int {
+	return l.offset
+}
+
+func (l *fixedLocation) GetLocation() (int, int) {
+	return l.line, l.col
+}
+
+// TokenState is a ParserState over a slice of tokens, it lets the usual
+// combinators work on the output of a Tokenizer. Rune based parsers only see
+// the first rune of the current token and skip the whole token when they
+// match, so token parsers like ExpectToken should be used instead.
+type TokenState struct {
+	source string
+	tokens []Token
+	index  int
+
+	user    interface{}
+	session *parseSession
+}
+
+// NewTokenState creates a TokenState at the beginning of the given tokens
+func NewTokenState(tokens []Token) *TokenState {
+	return &TokenState{tokens: tokens}
+}
+
+// CurrentToken returns the current token or nil at the end of the stream
+func (s *TokenState) CurrentToken() *Token {
+	if s.index >= len(s.tokens) {
+		return nil
+	}
+
+	return &s.tokens[s.index]
+}
+
+// CurrentRune returns the first rune of the current token or 0 at the end of the stream
+func (s *TokenState) CurrentRune() rune {
+	tok := s.CurrentToken()
+	if tok == nil {
+		return 0
+	}
+
+	r, _ := utf8.DecodeRuneInString(tok.Text)
+	return r
+}
+
+// Remaining ...
+func (s *TokenState) Remaining() ParserState {
+	next := *s
+	next.index++
+	return &next
+}
+
+// Offset returns the index of the current token
+func (s *TokenState) Offset() int {
+	return s.index
+}
+
+// GetLocation returns the location of the current token, or of the end of the last one
+func (s *TokenState) GetLocation() (int, int) {
+	if tok := s.CurrentToken(); tok != nil {
+		return tok.Line - 1, tok.Column
+	}
+
+	if len(s.tokens) == 0 {
+		return 0, 1
+	}
+
+	last := s.tokens[len(s.tokens)-1]
+	line, col := last.Line-1, last.Column
+	for _, r := range last.Text {
+		if r == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+
+	return line, col
+}
+
+// textUntil returns the source text covered by the consumed tokens, when the
+// source is unknown the token texts are joined by spaces
+func (s *TokenState) textUntil(end ParserState) string {
+	e, ok := end.(*TokenState)
+	if !ok || e.index <= s.index || s.index >= len(s.tokens) {
+		return ""
+	}
+
+	to := e.index
+	if to > len(s.tokens) {
+		to = len(s.tokens)
+	}
+
+	if s.source == "" {
+		texts := []string{}
+		for _, tok := range s.tokens[s.index:to] {
+			texts = append(texts, tok.Text)
+		}
+		return strings.Join(texts, " ")
+	}
+
+	last := s.tokens[to-1]
+	return s.source[s.tokens[s.index].Offset : last.Offset+len(last.Text)]
+}
+
+func (s *TokenState) parseSession() *parseSession {
+	return s.session
+}
+
+// ExpectToken creates a Parser that expects a token of the given kind, the Result is the Token
+func ExpectToken(kind string) Parser {
+	return newCombinator(Description{Kind: KindExpectToken, Text: kind}, func(state ParserState) (*ParserResult, error) {
+		return expectToken(state, kind, func(tok *Token) bool {
+			return tok.Kind == kind
+		})
+	})
+}
+
+// ExpectTokenText creates a Parser that expects a token with the given text, like a keyword or an operator
+func ExpectTokenText(text string) Parser {
+	return newCombinator(Description{Kind: KindExpectTokenText, Text: text}, func(state ParserState) (*ParserResult, error) {
+		return expectToken(state, fmt.Sprintf("%q", text), func(tok *Token) bool {
+			return tok.Text == text
+		})
+	})
+}
+
+func expectToken(state ParserState, description string, predicate func(*Token) bool) (*ParserResult, error) {
+	ts, ok := state.(*TokenState)
+	if !ok {
+		return Fail(state, fmt.Errorf(`Expected %s, token parsers require a TokenState`, description))
+	}
+
+	tok := ts.CurrentToken()
+	if tok == nil {
+		return Fail(state, ErrorAt(state, fmt.Errorf(`Stream ended, expected %s`, description)))
+	}
+
+	if !predicate(tok) {
+		return Fail(state, ErrorAt(state, fmt.Errorf(`Expected %s, found %s %q`, description, tok.Kind, tok.Text)))
+	}
+
+	return Success(state.Remaining(), *tok)
+}
+
+// ParseTokens - trivial
+func ParseTokens(parser Parser, tokens []Token, opts ...ParseOption) (interface{}, error) {
+	return parseTokenState(parser, NewTokenState(tokens), newParseSession(opts))
+}
+
+func parseTokenState(parser Parser, s *TokenState, session *parseSession) (result interface{}, err error) {
+	s.session = session
+	s.user = session.userState
+	defer recoverPanic(s, &result, &err)
+
+	return session.result(parser.Apply(s))
+}
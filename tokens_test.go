@@ -0,0 +1,75 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTokenizer = NewTokenizer().
+	Literal("keyword", "let", "in").
+	Literal("operator", "=", "==", "+").
+	Regexp("ident", `[a-zA-Z_][a-zA-Z0-9_]*`).
+	Regexp("number", `[0-9]+`).
+	Skip(`\s+`).
+	Skip(`#[^\n]*`)
+
+func TestTokenize(t *testing.T) {
+	{
+		tokens, err := testTokenizer.TokenizeString("let x == 10 # comment\n  in lets")
+		assert.Nil(t, err)
+		assert.Equal(t, []Token{
+			{"keyword", "let", 0, 1, 1},
+			{"ident", "x", 4, 1, 5},
+			{"operator", "==", 6, 1, 7},
+			{"number", "10", 9, 1, 10},
+			{"keyword", "in", 24, 2, 3},
+			{"ident", "lets", 27, 2, 6},
+		}, tokens)
+	}
+	{
+		_, err := testTokenizer.TokenizeString("let x = 1\n  $")
+		assert.EqualError(t, err, `Unexpected character "$" at 2:3`)
+	}
+}
+
+func TestTokenState(t *testing.T) {
+	text := func(i interface{}) interface{} {
+		return i.(Token).Text
+	}
+
+	term := Transform(AnyOf(ExpectToken("ident"), ExpectToken("number")), text)
+	sum := SeqOf(term, ZeroOrMore(SeqOf(SeqIgnore(ExpectTokenText("+")), term)))
+	let := SeqOf(
+		SeqIgnore(ExpectTokenText("let")),
+		Transform(ExpectToken("ident"), text),
+		SeqIgnore(ExpectTokenText("=")),
+		sum,
+		SeqIgnore(ExpectTokenText("in")),
+		sum,
+	)
+
+	{
+		r, err := testTokenizer.Parse(let, strings.NewReader("let x = 1 + y in x + 2"))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{
+			"x",
+			[]interface{}{"1", []interface{}{[]interface{}{"y"}}},
+			[]interface{}{"x", []interface{}{[]interface{}{"2"}}},
+		}, r)
+	}
+	{
+		_, err := testTokenizer.Parse(let, strings.NewReader("let x = 1\nout x"))
+		assert.EqualError(t, err, `Expected "in", found ident "out" at 2:1`)
+	}
+	{
+		tokens, _ := testTokenizer.TokenizeString("let   x")
+		_, err := ParseTokens(let, tokens)
+		assert.EqualError(t, err, `Stream ended, expected "=" at 1:8`)
+	}
+	{
+		r, _ := testTokenizer.Parse(StringifyResult(OneOrMore(Expect('+'))), strings.NewReader("+ + +"))
+		assert.Equal(t, "+++", r)
+	}
+}
@@ -0,0 +1,142 @@
+package combinators
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TraceNode records a single invocation of a parser
+type TraceNode struct {
+	Name string `json:"name"`
+
+	// Offset, Line and Column locate the start of the invocation, lines and columns are one based
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// End is the offset after the consumed input, equal to Offset on failure
+	End int `json:"end"`
+
+	Success  bool   `json:"success"`
+	Consumed string `json:"consumed,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	Children []*TraceNode `json:"children,omitempty"`
+}
+
+// Tracer records every built-in parser entered during a parse as a tree of
+// TraceNodes, see WithTracer. Opaque FuncParsers are not recorded but the
+// built-in parsers they call are.
+type Tracer struct {
+	roots []*TraceNode
+	stack []*TraceNode
+}
+
+// NewTracer creates an empty Tracer
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// WithTracer records the parse with the given Tracer
+func WithTracer(tracer *Tracer) ParseOption {
+	return func(session *parseSession) {
+		session.tracer = tracer
+	}
+}
+
+// Roots returns the outermost recorded invocations
+func (t *Tracer) Roots() []*TraceNode {
+	return t.roots
+}
+
+// Reset discards everything recorded so far
+func (t *Tracer) Reset() {
+	t.roots = nil
+	t.stack = nil
+}
+
+func (t *Tracer) record(name string, state ParserState, fn FuncParser) (*ParserResult, error) {
+	node := &TraceNode{Name: name, Line: 1, Column: 1}
+	if locator, ok := state.(Locator); ok {
+		line, col := locator.GetLocation()
+		node.Offset, node.Line, node.Column = locator.Offset(), line+1, col
+	}
+	node.End = node.Offset
+
+	if len(t.stack) == 0 {
+		t.roots = append(t.roots, node)
+	} else {
+		parent := t.stack[len(t.stack)-1]
+		parent.Children = append(parent.Children, node)
+	}
+
+	t.stack = append(t.stack, node)
+	pr, err := t.apply(state, fn)
+
+	if err != nil {
+		node.Error = err.Error()
+		return pr, err
+	}
+
+	node.Success = true
+	node.Consumed = ConsumedText(state, pr.Remaining)
+	if locator, ok := pr.Remaining.(Locator); ok {
+		node.End = locator.Offset()
+	}
+
+	return pr, nil
+}
+
+// apply applies fn with node on top of the stack, popping it even if fn
+// panics, as the panics are recovered into parse errors and the Tracer can be
+// reused
+func (t *Tracer) apply(state ParserState, fn FuncParser) (*ParserResult, error) {
+	defer func() { t.stack = t.stack[:len(t.stack)-1] }()
+	return fn(state)
+}
+
+// WriteTree writes the recorded invocations as an indented tree, one per line
+func (t *Tracer) WriteTree(w io.Writer) error {
+	for _, root := range t.roots {
+		if err := writeTraceNode(w, root, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTraceNode(w io.Writer, node *TraceNode, depth int) error {
+	var outcome string
+	if node.Success {
+		outcome = fmt.Sprintf("ok %q", node.Consumed)
+	} else {
+		outcome = "fail: " + strings.SplitN(node.Error, "\n", 2)[0]
+	}
+
+	_, err := fmt.Fprintf(w, "%s%s at %d:%d %s\n", strings.Repeat("  ", depth), node.Name, node.Line, node.Column, outcome)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range node.Children {
+		if err := writeTraceNode(w, child, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// String returns the recorded invocations as an indented tree
+func (t *Tracer) String() string {
+	sb := &strings.Builder{}
+	t.WriteTree(sb)
+	return sb.String()
+}
+
+// WriteJSON writes the recorded invocations as a JSON array of TraceNodes
+func (t *Tracer) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t.roots)
+}
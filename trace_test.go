@@ -0,0 +1,56 @@
+package combinators
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracer(t *testing.T) {
+	parser := SeqOf(Expect('a'), AnyOf(Expect('x'), ExpectString([]rune("b\nc"))), Optional(Digit))
+
+	tracer := NewTracer()
+	r, err := ParseRuneReader(parser, strings.NewReader("ab\nc!"), WithTracer(tracer))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "b\nc", nil}, r)
+
+	assert.Equal(t, `SeqOf at 1:1 ok "ab\nc"
+  Expect('a') at 1:1 ok "a"
+  AnyOf at 1:2 ok "b\nc"
+    Expect('x') at 1:2 fail: Expected "x"
+    ExpectString("b\nc") at 1:2 ok "b\nc"
+  Optional at 2:2 ok ""
+    ExpectPredicate(digit) at 2:2 fail: Expected "[digit]"
+`, tracer.String())
+
+	sb := &strings.Builder{}
+	assert.Nil(t, tracer.WriteJSON(sb))
+
+	nodes := []*TraceNode{}
+	assert.Nil(t, json.Unmarshal([]byte(sb.String()), &nodes))
+	assert.Equal(t, tracer.Roots(), nodes)
+
+	anyOf := nodes[0].Children[1]
+	assert.Equal(t, &TraceNode{
+		Name: `ExpectString("b\nc")`, Offset: 1, Line: 1, Column: 2, End: 4,
+		Success: true, Consumed: "b\nc",
+	}, anyOf.Children[1])
+}
+
+func TestTracerAfterPanic(t *testing.T) {
+	panicking := Transform(Expect('a'), func(i interface{}) interface{} {
+		panic("broken transform")
+	})
+
+	tracer := NewTracer()
+	_, err := ParseRuneReader(SeqOf(panicking), strings.NewReader("a"), WithTracer(tracer))
+	assert.NotNil(t, err)
+
+	// the next parse starts a new root instead of nesting in the interrupted one
+	_, err = ParseRuneReader(Expect('b'), strings.NewReader("b"), WithTracer(tracer))
+	assert.Nil(t, err)
+	assert.Len(t, tracer.Roots(), 2)
+	assert.Equal(t, "Expect('b')", tracer.Roots()[1].Name)
+}
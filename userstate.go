@@ -0,0 +1,135 @@
+package combinators
+
+import (
+	"fmt"
+)
+
+// UserStateHolder is implemented by parser states carrying a user defined
+// value through the parse. States are immutable so backtracking in AnyOf,
+// Optional and the other combinators automatically restores the previous
+// value, for this to work the value itself must never be mutated in place:
+// always replace it with an updated copy.
+type UserStateHolder interface {
+	UserState() interface{}
+	WithUserState(value interface{}) ParserState
+}
+
+// UserState returns the current user state
+func (s *RuneScanner) UserState() interface{} {
+	return s.user
+}
+
+// WithUserState returns a copy of the state with a new user state
+func (s *RuneScanner) WithUserState(value interface{}) ParserState {
+	next := *s
+	next.user = value
+	return &next
+}
+
+// UserState returns the current user state
+func (s *TokenState) UserState() interface{} {
+	return s.user
+}
+
+// WithUserState returns a copy of the state with a new user state
+func (s *TokenState) WithUserState(value interface{}) ParserState {
+	next := *s
+	next.user = value
+	return &next
+}
+
+// WithUserState sets the initial user state of a parse
+func WithUserState(value interface{}) ParseOption {
+	return func(session *parseSession) {
+		session.userState = value
+	}
+}
+
+func stateHolder(state ParserState) (UserStateHolder, error) {
+	holder, ok := state.(UserStateHolder)
+	if !ok {
+		return nil, fmt.Errorf(`User state is not supported by %T`, state)
+	}
+
+	return holder, nil
+}
+
+// GetState creates a Parser that consumes nothing and returns the current user state
+func GetState() Parser {
+	return newCombinator(Description{Kind: KindGetState}, func(state ParserState) (*ParserResult, error) {
+		holder, err := stateHolder(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return Success(state, holder.UserState())
+	})
+}
+
+// SetState creates a Parser that consumes nothing, replaces the user state
+// with the given value and returns it
+func SetState(value interface{}) Parser {
+	return newCombinator(Description{Kind: KindSetState}, func(state ParserState) (*ParserResult, error) {
+		holder, err := stateHolder(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return Success(holder.WithUserState(value), value)
+	})
+}
+
+// UpdateState runs a parser and then replaces the user state with the value
+// computed by "update" from the current user state and the parser result,
+// the Result is the one of the parser
+func UpdateState(parser Parser, update func(userState, result interface{}) interface{}) Parser {
+	return newCombinator(Description{Kind: KindUpdateState, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		pr, err := parser.Apply(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		holder, err := stateHolder(pr.Remaining)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return Success(holder.WithUserState(update(holder.UserState(), pr.Result)), pr.Result)
+	})
+}
+
+// CheckState runs a parser and then fails if "check" returns an error for the
+// current user state and the parser result, for example to only accept
+// identifiers previously declared as type names
+func CheckState(parser Parser, check func(userState, result interface{}) error) Parser {
+	return newCombinator(Description{Kind: KindCheckState, Children: []Parser{parser}}, func(state ParserState) (*ParserResult, error) {
+		pr, err := parser.Apply(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		holder, err := stateHolder(pr.Remaining)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		if err := check(holder.UserState(), pr.Result); err != nil {
+			return Fail(state, ErrorAt(state, err))
+		}
+
+		return pr, nil
+	})
+}
+
+// FromState creates a Parser that builds the actual parser from the current
+// user state, for example to match the closing delimiter of a heredoc
+func FromState(build func(userState interface{}) Parser) Parser {
+	return newCombinator(Description{Kind: KindFromState}, func(state ParserState) (*ParserResult, error) {
+		holder, err := stateHolder(state)
+		if err != nil {
+			return Fail(state, err)
+		}
+
+		return build(holder.UserState()).Apply(state)
+	})
+}
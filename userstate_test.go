@@ -0,0 +1,113 @@
+package combinators
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserStateBacktracking(t *testing.T) {
+	parser := SeqOf(
+		AnyOf(
+			SeqOf(SetState("first"), Expect('a'), Expect('b')),
+			SeqOf(Expect('a'), Expect('c')),
+		),
+		Optional(SeqOf(SetState("optional"), Expect('x'))),
+		GetState(),
+	)
+
+	{
+		r, _ := ParseRuneReader(parser, strings.NewReader("ab"), WithUserState("initial"))
+		assert.Equal(t, []interface{}{[]interface{}{"first", "a", "b"}, nil, "first"}, r)
+	}
+	{
+		r, _ := ParseRuneReader(parser, strings.NewReader("acy"), WithUserState("initial"))
+		assert.Equal(t, []interface{}{[]interface{}{"a", "c"}, nil, "initial"}, r)
+	}
+	{
+		r, _ := ParseRuneReader(parser, strings.NewReader("acx"), WithUserState("initial"))
+		assert.Equal(t, []interface{}{[]interface{}{"a", "c"}, []interface{}{"optional", "x"}, "optional"}, r)
+	}
+}
+
+func TestTypedefNames(t *testing.T) {
+	// A tiny C like language where "a * b;" is a declaration if "a" was
+	// previously declared with "typedef" and a multiplication otherwise
+	ident := StringifyResult(OneOrMore(Letter))
+
+	typedef := Transform(
+		UpdateState(
+			SeqOf(SeqIgnore(ExpectString([]rune("typedef "))), ident),
+			func(userState, result interface{}) interface{} {
+				types := map[string]bool{}
+				for name := range userState.(map[string]bool) {
+					types[name] = true
+				}
+				types[result.([]interface{})[0].(string)] = true
+				return types
+			},
+		),
+		func(i interface{}) interface{} {
+			return "typedef " + i.([]interface{})[0].(string)
+		},
+	)
+
+	typeName := CheckState(ident, func(userState, result interface{}) error {
+		if !userState.(map[string]bool)[result.(string)] {
+			return fmt.Errorf(`"%s" is not a type`, result)
+		}
+		return nil
+	})
+
+	declaration := Transform(
+		SeqOf(typeName, SeqIgnore(ExpectString([]rune(" * "))), ident),
+		func(i interface{}) interface{} {
+			return "declare " + StringifyInterfaces(i)
+		},
+	)
+
+	multiplication := Transform(
+		SeqOf(ident, SeqIgnore(ExpectString([]rune(" * "))), ident),
+		func(i interface{}) interface{} {
+			return "multiply " + StringifyInterfaces(i)
+		},
+	)
+
+	program := OneOrMore(
+		Transform(
+			SeqOf(AnyOf(typedef, declaration, multiplication), SeqIgnore(ExpectString([]rune(";\n")))),
+			func(i interface{}) interface{} { return i.([]interface{})[0] },
+		),
+	)
+
+	r, err := ParseRuneReader(program, strings.NewReader("a * b;\ntypedef a;\na * b;\nc * d;\n"), WithUserState(map[string]bool{}))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"multiply ab", "typedef a", "declare ab", "multiply cd"}, r)
+
+	_, err = ParseRuneReader(SeqOf(typeName, EOF), strings.NewReader("x"), WithUserState(map[string]bool{}))
+	assert.EqualError(t, err, `"x" is not a type at 1:1`)
+}
+
+func TestHeredoc(t *testing.T) {
+	line := StringifyResult(SeqOf(ZeroOrMore(ExpectPredicate(func(r rune) bool { return r != '\n' })), Newline))
+
+	heredoc := Transform(
+		SeqOf(
+			SeqIgnore(ExpectString([]rune("<<"))),
+			SeqIgnore(UpdateState(StringifyResult(OneOrMore(Letter)), func(_, result interface{}) interface{} {
+				return result
+			})),
+			SeqIgnore(Newline),
+			StringifyResult(RepeatUntil(line, FromState(func(delimiter interface{}) Parser {
+				return ExpectString([]rune(delimiter.(string)))
+			}))),
+		),
+		func(i interface{}) interface{} { return i.([]interface{})[0] },
+	)
+
+	r, err := ParseRuneReader(heredoc, strings.NewReader("<<END\nsome\nEN text\nEND"))
+	assert.Nil(t, err)
+	assert.Equal(t, "some\nEN text\n", r)
+}
@@ -0,0 +1,549 @@
+package combinators
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Program is a Parser compiled to the bytecode of a parsing virtual machine
+// in the style of LPeg: a single loop runs the instructions keeping the
+// backtrack points, the calls of rules and the results on explicit stacks,
+// without the allocations of the intermediate parser states. Results and
+// errors are the same as the ones of the compiled parser.
+//
+// The combinators the machine doesn't know, like FuncParsers and the ones
+// depending on trivia, indentation, tokens or the user state, are run by the
+// interpreter. Traced parses are entirely interpreted.
+type Program struct {
+	root Parser
+	code []instruction
+	// rules are the names of the subroutines of Named rules by address
+	rules map[int]string
+}
+
+type opcode int
+
+const (
+	// opChar matches the rune r
+	opChar opcode = iota
+	// opSet matches a rune of set
+	opSet
+	// opPredicate matches a rune satisfying predicate
+	opPredicate
+	// opString matches the runes
+	opString
+	// opEOF matches the end of the input
+	opEOF
+	// opPush pushes value
+	opPush
+	// opRaise fails with err
+	opRaise
+	// opFallback runs parser with the interpreter
+	opFallback
+
+	// opChoice pushes a backtrack point that on failure restores the
+	// position and jumps to addr
+	opChoice
+	// opCommit pops the last backtrack point and jumps to addr
+	opCommit
+	// opBackCommit pops the last backtrack point, restores its position and jumps to addr
+	opBackCommit
+	// opCall calls the subroutine at addr
+	opCall
+	// opReturn returns from a subroutine
+	opReturn
+	// opJump jumps to addr
+	opJump
+	// opJumpIfEOF jumps to addr at the end of the input
+	opJumpIfEOF
+	// opFail fails at the current position with the current error
+	opFail
+	// opAdvance moves to the position of the last failure
+	opAdvance
+	// opHalt ends the program successfully
+	opHalt
+
+	// opCollect pops n values in a []interface{}
+	opCollect
+	// opPop drops the top value
+	opPop
+	// opNewList pushes an empty []interface{}
+	opNewList
+	// opWrap replaces the top value with a []interface{} containing it
+	opWrap
+	// opAppend pops the top value and appends it to the list under it
+	opAppend
+	// opSlide drops n values under the top one
+	opSlide
+	// opMark pushes the current position, for the opTransform that pops it
+	opMark
+	// opTransform applies transform to the top value and drops the position
+	// pushed by opMark under it
+	opTransform
+
+	// opSaveError pushes the current error
+	opSaveError
+	// opAllFailed pops n errors and fails with all of them, as AnyOf does
+	opAllFailed
+	// opNamed adds the rule text to the current error and fails, as Named does
+	opNamed
+	// opLabel replaces the current error with a label and fails, as Label does
+	opLabel
+	// opNot pops the last backtrack point and fails with the text matched since it, as Not does
+	opNot
+)
+
+var opcodeNames = [...]string{
+	opChar: "char", opSet: "set", opPredicate: "predicate", opString: "string", opEOF: "eof",
+	opPush: "push", opRaise: "raise", opFallback: "fallback",
+	opChoice: "choice", opCommit: "commit", opBackCommit: "backcommit", opCall: "call",
+	opReturn: "return", opJump: "jump", opJumpIfEOF: "jumpifeof", opFail: "fail",
+	opAdvance: "advance", opHalt: "halt",
+	opCollect: "collect", opPop: "pop", opNewList: "newlist", opWrap: "wrap", opAppend: "append",
+	opSlide: "slide", opMark: "mark", opTransform: "transform",
+	opSaveError: "saveerror", opAllFailed: "allfailed", opNamed: "named", opLabel: "label", opNot: "not",
+}
+
+type instruction struct {
+	op opcode
+	// addr is the target of jumps or a count
+	addr int
+
+	r         rune
+	runes     []rune
+	set       *runeSet
+	predicate func(rune) bool
+	value     interface{}
+	// text is the rule of Named and the label of Label
+	text      string
+	parser    Parser
+	transform func(interface{}) interface{}
+
+	// errEnd is the error at the end of the input and err the one for
+	// unexpected runes, errs are the errors of each rune of opString
+	errEnd, err error
+	errs        []error
+}
+
+func (ins instruction) String() string {
+	switch ins.op {
+	case opChar:
+		return fmt.Sprintf("char %q", ins.r)
+	case opSet:
+		return fmt.Sprintf("set %q", string(ins.runes))
+	case opString:
+		return fmt.Sprintf("string %q", string(ins.runes))
+	case opPredicate, opNamed, opLabel:
+		return fmt.Sprintf("%s %s", opcodeNames[ins.op], ins.text)
+	case opPush:
+		return fmt.Sprintf("push %#v", ins.value)
+	case opFallback:
+		return fmt.Sprintf("fallback %s", Describe(ins.parser))
+	case opChoice, opCommit, opBackCommit, opCall, opJump, opJumpIfEOF, opCollect, opSlide, opAllFailed:
+		return fmt.Sprintf("%s %d", opcodeNames[ins.op], ins.addr)
+	}
+
+	return opcodeNames[ins.op]
+}
+
+// String returns the listing of the program
+func (p *Program) String() string {
+	sb := &strings.Builder{}
+	for i, ins := range p.code {
+		fmt.Fprintf(sb, "%4d  %s\n", i, ins)
+	}
+
+	return sb.String()
+}
+
+// runeSet is a set of runes with a bitmap for ASCII
+type runeSet struct {
+	ascii  [2]uint64
+	others []rune
+}
+
+func newRuneSet(runes []rune) *runeSet {
+	set := &runeSet{}
+	for _, r := range runes {
+		if r < 128 {
+			set.ascii[r/64] |= 1 << uint(r%64)
+		} else {
+			set.others = append(set.others, r)
+		}
+	}
+
+	return set
+}
+
+func (set *runeSet) contains(r rune) bool {
+	if r < 128 {
+		return set.ascii[r/64]&(1<<uint(r%64)) != 0
+	}
+
+	for _, other := range set.others {
+		if other == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runeValues are the results of single ASCII runes, boxed once
+var runeValues [128]interface{}
+
+func init() {
+	for r := range runeValues {
+		runeValues[r] = string(rune(r))
+	}
+}
+
+func runeValue(r rune) interface{} {
+	if r < 128 {
+		return runeValues[r]
+	}
+
+	return string(r)
+}
+
+// allFailed are the errors of the alternatives of AnyOf, formatted only when needed
+type allFailed []error
+
+func (errs allFailed) Error() string {
+	lines := []string{}
+	for _, err := range errs {
+		lines = append(lines, fmt.Sprintf(" - %v", err))
+	}
+
+	return fmt.Sprintf("All cases failed:\n%s", strings.Join(lines, "\n"))
+}
+
+type frame struct {
+	// addr is the failure handler of a backtrack point or the return address of a call
+	addr int
+	call bool
+	pos  int
+	// values is the height of the value stack
+	values int
+	// ctx holds the trivia, indentation and user state of the position
+	ctx *RuneScanner
+}
+
+// at returns a state at another position with the same context
+func (s *RuneScanner) at(cursor int) *RuneScanner {
+	next := *s
+	next.cursor = cursor
+	return &next
+}
+
+// Apply runs the program, it's interpreted for states other than RuneScanners
+func (p *Program) Apply(state ParserState) (*ParserResult, error) {
+	if s, ok := state.(*RuneScanner); ok && !s.input.session.observed() {
+		return p.run(s)
+	}
+
+	return p.root.Apply(state)
+}
+
+// transformAt calls the function of a Transform, locating its panics at the
+// beginning of the transformed text like applyTransform
+func transformAt(state ParserState, transform func(interface{}) interface{}, value interface{}) interface{} {
+	defer func() {
+		if r := recover(); r != nil {
+			panic(located(state, r))
+		}
+	}()
+
+	return transform(value)
+}
+
+// located converts a panic of the program to a parsePanic with the stack of
+// the rules being called
+func (p *Program) located(frames []frame, state ParserState, r interface{}) *parsePanic {
+	panicked := located(state, r)
+
+	for i := len(frames) - 1; i >= 0; i-- {
+		if !frames[i].call {
+			continue
+		}
+
+		if name, ok := p.rules[p.code[frames[i].addr-1].addr]; ok {
+			panicked.err = withRule(state, panicked.err, name)
+		}
+	}
+
+	return panicked
+}
+
+// Describe - see Describable, a Program is described as the parser it was compiled from
+func (p *Program) Describe() Description {
+	return Describe(p.root)
+}
+
+func (p *Program) run(start *RuneScanner) (*ParserResult, error) {
+	in := start.input
+	ctx := start
+	pos := start.cursor
+
+	session := in.session
+	guarded := session.guarded
+
+	values := make([]interface{}, 0, 16)
+	frames := make([]frame, 0, 16)
+
+	defer func() {
+		if r := recover(); r != nil {
+			panic(p.located(frames, ctx.at(pos), r))
+		}
+	}()
+
+	var err error
+	failPos := 0
+
+	pc := 0
+	for {
+		ins := &p.code[pc]
+		pc++
+
+		switch ins.op {
+		case opChar:
+			switch r := in.at(pos); {
+			case r == 0:
+				err = ins.errEnd
+			case r == ins.r:
+				values = append(values, ins.value)
+				pos++
+				continue
+			default:
+				err = ins.err
+			}
+			failPos = pos
+
+		case opSet:
+			switch r := in.at(pos); {
+			case r == 0:
+				err = ins.errEnd
+			case ins.set.contains(r):
+				values = append(values, runeValue(r))
+				pos++
+				continue
+			default:
+				err = ins.err
+			}
+			failPos = pos
+
+		case opPredicate:
+			switch r := in.at(pos); {
+			case r == 0:
+				err = ins.errEnd
+			case ins.predicate(r):
+				values = append(values, runeValue(r))
+				pos++
+				continue
+			default:
+				err = ins.err
+			}
+			failPos = pos
+
+		case opString:
+			if in.at(pos) == 0 {
+				err, failPos = ins.errEnd, pos
+				break
+			}
+
+			matched := true
+			for i, r := range ins.runes {
+				if in.at(pos+i) != r {
+					err, failPos = ins.errs[i], pos+i
+					matched = false
+					break
+				}
+			}
+			if !matched {
+				break
+			}
+
+			values = append(values, ins.value)
+			pos += len(ins.runes)
+			continue
+
+		case opEOF:
+			if in.at(pos) == 0 {
+				values = append(values, ins.value)
+				pos++
+				continue
+			}
+			err, failPos = ins.err, pos
+
+		case opPush:
+			values = append(values, ins.value)
+			continue
+
+		case opRaise:
+			err, failPos = ins.err, pos
+
+		case opFallback:
+			pr, e := ins.parser.Apply(ctx.at(pos))
+			var remaining *RuneScanner
+			if pr != nil {
+				remaining, _ = pr.Remaining.(*RuneScanner)
+			}
+
+			if e == nil {
+				values = append(values, pr.Result)
+				if remaining != nil {
+					pos, ctx = remaining.cursor, remaining
+				}
+				continue
+			}
+
+			err, failPos = e, pos
+			if remaining != nil {
+				failPos = remaining.cursor
+			}
+
+		case opChoice:
+			if guarded {
+				if e := session.check(session.depth + len(frames)); e != nil {
+					return Fail(ctx.at(pos), session.abort(ctx.at(pos), e))
+				}
+			}
+			frames = append(frames, frame{addr: ins.addr, pos: pos, values: len(values), ctx: ctx})
+			continue
+
+		case opCommit:
+			frames = frames[:len(frames)-1]
+			pc = ins.addr
+			continue
+
+		case opBackCommit:
+			f := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			pos, ctx = f.pos, f.ctx
+			pc = ins.addr
+			continue
+
+		case opCall:
+			if guarded {
+				if e := session.check(session.depth + len(frames)); e != nil {
+					return Fail(ctx.at(pos), session.abort(ctx.at(pos), e))
+				}
+			}
+			frames = append(frames, frame{addr: pc, call: true})
+			pc = ins.addr
+			continue
+
+		case opReturn:
+			pc = frames[len(frames)-1].addr
+			frames = frames[:len(frames)-1]
+			continue
+
+		case opJump:
+			pc = ins.addr
+			continue
+
+		case opJumpIfEOF:
+			if in.at(pos) == 0 {
+				pc = ins.addr
+			}
+			continue
+
+		case opFail:
+			failPos = pos
+
+		case opAdvance:
+			pos = failPos
+			continue
+
+		case opHalt:
+			return Success(ctx.at(pos), values[len(values)-1])
+
+		case opCollect:
+			n := len(values) - ins.addr
+			collected := make([]interface{}, ins.addr)
+			copy(collected, values[n:])
+			values = append(values[:n], collected)
+			continue
+
+		case opPop:
+			values = values[:len(values)-1]
+			continue
+
+		case opNewList:
+			values = append(values, []interface{}{})
+			continue
+
+		case opWrap:
+			values[len(values)-1] = []interface{}{values[len(values)-1]}
+			continue
+
+		case opAppend:
+			n := len(values) - 1
+			values[n-1] = append(values[n-1].([]interface{}), values[n])
+			values = values[:n]
+			continue
+
+		case opSlide:
+			n := len(values) - 1
+			values[n-ins.addr] = values[n]
+			values = values[:n-ins.addr+1]
+			continue
+
+		case opMark:
+			values = append(values, ctx.at(pos))
+			continue
+
+		case opTransform:
+			n := len(values) - 1
+			values[n-1] = transformAt(values[n-1].(*RuneScanner), ins.transform, values[n])
+			values = values[:n]
+			continue
+
+		case opSaveError:
+			values = append(values, err)
+			continue
+
+		case opAllFailed:
+			n := len(values) - ins.addr
+			errs := make(allFailed, ins.addr)
+			for i, v := range values[n:] {
+				errs[i] = v.(error)
+			}
+			values = values[:n]
+			err, failPos = errs, pos
+
+		case opNamed:
+			err, failPos = withRule(ctx.at(failPos), err, ins.text), pos
+
+		case opLabel:
+			pe, ok := err.(*ParseError)
+			if !ok || pe.Offset <= pos {
+				if in.at(pos) == 0 {
+					err = ErrorAt(ctx.at(pos), ins.errEnd)
+				} else {
+					err = ErrorAt(ctx.at(pos), ins.err)
+				}
+			}
+			failPos = pos
+
+		case opNot:
+			f := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			text := ctx.at(f.pos).textUntil(ctx.at(pos))
+			pos, ctx, values = f.pos, f.ctx, values[:f.values]
+			err, failPos = ErrorAt(ctx.at(pos), fmt.Errorf(`Unexpected "%s"`, text)), pos
+		}
+
+		// the instruction failed: backtrack to the last choice
+		for len(frames) > 0 && frames[len(frames)-1].call {
+			frames = frames[:len(frames)-1]
+		}
+		if len(frames) == 0 {
+			return Fail(ctx.at(failPos), err)
+		}
+
+		f := frames[len(frames)-1]
+		frames = frames[:len(frames)-1]
+		pos, ctx, values, pc = f.pos, f.ctx, values[:f.values], f.addr
+	}
+}
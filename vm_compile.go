@@ -0,0 +1,336 @@
+package combinators
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Compile compiles a parser to a Program for the parsing virtual machine, it
+// can be used in place of the parser. Named rules, the targets of Lazy and
+// the parsers used more than once are compiled once as subroutines.
+func Compile(parser Parser) *Program {
+	comp := &compiler{refs: map[Parser]int{}, subroutines: map[Parser]int{}}
+	comp.count(parser)
+
+	comp.compile(parser)
+	comp.emit(instruction{op: opHalt})
+
+	// subroutines can call more subroutines, that are appended to the queue
+	addrs := []int{}
+	rules := map[int]string{}
+	for i := 0; i < len(comp.queue); i++ {
+		addrs = append(addrs, len(comp.code))
+		if desc := Describe(comp.queue[i]); desc.Kind == KindNamed {
+			rules[len(comp.code)] = desc.Label
+		}
+
+		comp.body(comp.queue[i])
+		comp.emit(instruction{op: opReturn})
+	}
+
+	for _, call := range comp.calls {
+		comp.code[call].addr = addrs[comp.code[call].addr]
+	}
+
+	return &Program{root: parser, code: comp.code, rules: rules}
+}
+
+type compiler struct {
+	code []instruction
+	// refs counts the parents of each parser
+	refs map[Parser]int
+	// subroutines are the indices in queue of the parsers compiled as subroutines
+	subroutines map[Parser]int
+	queue       []Parser
+	// calls are the call instructions, their addr is an index in queue until
+	// all the subroutines are compiled
+	calls []int
+}
+
+func comparableParser(parser Parser) bool {
+	return reflect.TypeOf(parser).Comparable()
+}
+
+func (comp *compiler) count(parser Parser) {
+	if !comparableParser(parser) {
+		return
+	}
+
+	comp.refs[parser]++
+	if comp.refs[parser] > 1 {
+		return
+	}
+
+	for _, child := range Describe(parser).Children {
+		comp.count(child)
+	}
+}
+
+func (comp *compiler) emit(ins instruction) int {
+	comp.code = append(comp.code, ins)
+	return len(comp.code) - 1
+}
+
+// here returns the address of the next instruction
+func (comp *compiler) here() int {
+	return len(comp.code)
+}
+
+// native tells if the machine runs a kind of combinator, the others are interpreted
+func native(kind Kind) bool {
+	switch kind {
+	case KindExpect, KindExpectPredicate, KindExpectAny, KindExpectString, KindEOF,
+		KindSeqOf, KindAnyOf, KindRepeatUntil, KindOneOrMore, KindZeroOrMore, KindOptional,
+		KindNot, KindLookahead, KindTransform, KindNamed, KindLabel:
+		return true
+	}
+
+	return false
+}
+
+// compile emits the code of a parser: on success it leaves its result on the
+// value stack, on failure it fails at the position the parser would report
+func (comp *compiler) compile(parser Parser) {
+	desc := Describe(parser)
+
+	switch desc.Kind {
+	case KindLazy:
+		comp.call(desc.Children[0])
+		return
+	case KindSeqIgnore, KindRestarableOneOrMore, KindMemoize:
+		comp.compile(desc.Children[0])
+		return
+	case KindExpect, KindExpectPredicate, KindExpectAny, KindExpectString, KindEOF:
+		comp.body(parser)
+		return
+	}
+
+	if native(desc.Kind) && (desc.Kind == KindNamed || comparableParser(parser) && comp.refs[parser] > 1) {
+		comp.call(parser)
+		return
+	}
+
+	comp.body(parser)
+}
+
+func (comp *compiler) call(parser Parser) {
+	if !comparableParser(parser) {
+		comp.compile(parser)
+		return
+	}
+
+	index, ok := comp.subroutines[parser]
+	if !ok {
+		index = len(comp.queue)
+		comp.subroutines[parser] = index
+		comp.queue = append(comp.queue, parser)
+	}
+
+	comp.calls = append(comp.calls, comp.emit(instruction{op: opCall, addr: index}))
+}
+
+// failsAtStart tells if a parser always fails at its starting position
+func failsAtStart(parser Parser) bool {
+	desc := Describe(parser)
+
+	switch desc.Kind {
+	case KindLazy, KindSeqIgnore, KindRestarableOneOrMore, KindMemoize:
+		return failsAtStart(desc.Children[0])
+	case KindExpectString:
+		return len([]rune(desc.Text)) <= 1
+	case KindSeqOf:
+		return len(desc.Children) <= 1
+	}
+
+	return native(desc.Kind)
+}
+
+// compileAtStart compiles a parser that fails at its starting position
+func (comp *compiler) compileAtStart(parser Parser) {
+	if failsAtStart(parser) {
+		comp.compile(parser)
+		return
+	}
+
+	choice := comp.emit(instruction{op: opChoice})
+	comp.compile(parser)
+	commit := comp.emit(instruction{op: opCommit})
+	comp.code[choice].addr = comp.emit(instruction{op: opFail})
+	comp.code[commit].addr = comp.here()
+}
+
+// guarded compiles a parser between a backtrack point and a commit, the
+// failure handler is emitted after the commit and returns to its target
+func (comp *compiler) guarded(parser Parser, handler instruction) {
+	choice := comp.emit(instruction{op: opChoice})
+	comp.compile(parser)
+	commit := comp.emit(instruction{op: opCommit})
+	comp.code[choice].addr = comp.emit(handler)
+	comp.code[commit].addr = comp.here()
+}
+
+// body emits the code of a parser itself, mirroring its combinator
+func (comp *compiler) body(parser Parser) {
+	desc := Describe(parser)
+
+	switch desc.Kind {
+	case KindLazy, KindSeqIgnore, KindRestarableOneOrMore, KindMemoize:
+		comp.compile(desc.Children[0])
+
+	case KindExpect:
+		r := []rune(desc.Text)[0]
+		comp.emit(instruction{
+			op: opChar, r: r, value: runeValue(r),
+			errEnd: fmt.Errorf(`Stream ended, expected "%c"`, r),
+			err:    fmt.Errorf(`Expected "%c"`, r),
+		})
+
+	case KindExpectPredicate:
+		descriptions := strings.Split(desc.Text, ", ")
+		comp.emit(instruction{
+			op: opPredicate, predicate: desc.Predicate, text: desc.Text,
+			errEnd: fmt.Errorf(`Stream ended, expected "%v"`, descriptions),
+			err:    fmt.Errorf(`Expected "%+v"`, descriptions),
+		})
+
+	case KindExpectAny:
+		list := strings.Join(strings.Split(desc.Text, ""), ", ")
+		if desc.Text == "" {
+			comp.emit(instruction{op: opRaise, err: fmt.Errorf(`Expected one of %v`, list)})
+			return
+		}
+
+		comp.emit(instruction{
+			op: opSet, runes: []rune(desc.Text), set: newRuneSet([]rune(desc.Text)),
+			errEnd: fmt.Errorf(`Stream ended, expected one of %v`, list),
+			err:    fmt.Errorf(`Expected one of %v`, list),
+		})
+
+	case KindExpectString:
+		runes := []rune(desc.Text)
+		if len(runes) == 0 {
+			comp.emit(instruction{op: opPush, value: ""})
+			return
+		}
+
+		errs := []error{}
+		for _, r := range runes {
+			errs = append(errs, fmt.Errorf(`Expected "%c"`, r))
+		}
+		comp.emit(instruction{
+			op: opString, runes: runes, value: desc.Text, errs: errs,
+			errEnd: fmt.Errorf(`Stream ended, expected "%s"`, desc.Text),
+		})
+
+	case KindEOF:
+		comp.emit(instruction{op: opEOF, value: "\x00", err: fmt.Errorf(`Expected end of stream`)})
+
+	case KindSeqOf:
+		collected := 0
+		for _, child := range desc.Children {
+			comp.compileAtStart(child)
+			if _, ignored := child.(*seqIgnore); ignored {
+				comp.emit(instruction{op: opPop})
+			} else {
+				collected++
+			}
+		}
+		comp.emit(instruction{op: opCollect, addr: collected})
+
+	case KindAnyOf:
+		// the errors of the failed alternatives stay on the value stack
+		// until the last one fails or one succeeds
+		commits := []int{}
+		for i, child := range desc.Children {
+			choice := comp.emit(instruction{op: opChoice})
+			comp.compile(child)
+			if i > 0 {
+				comp.emit(instruction{op: opSlide, addr: i})
+			}
+			commits = append(commits, comp.emit(instruction{op: opCommit}))
+			comp.code[choice].addr = comp.emit(instruction{op: opSaveError})
+		}
+		comp.emit(instruction{op: opAllFailed, addr: len(desc.Children)})
+
+		for _, commit := range commits {
+			comp.code[commit].addr = comp.here()
+		}
+
+	case KindRepeatUntil:
+		outer := comp.emit(instruction{op: opChoice})
+		comp.emit(instruction{op: opNewList})
+		loop := comp.emit(instruction{op: opChoice})
+		comp.compile(desc.Children[1])
+		finish := comp.emit(instruction{op: opBackCommit})
+		comp.code[loop].addr = comp.here()
+		comp.compile(desc.Children[0])
+		comp.emit(instruction{op: opAppend})
+		comp.emit(instruction{op: opJump, addr: loop})
+		comp.code[finish].addr = comp.emit(instruction{op: opPop})
+		commit := comp.emit(instruction{op: opCommit})
+		comp.code[outer].addr = comp.emit(instruction{op: opFail})
+		comp.code[commit].addr = comp.here()
+
+	case KindOneOrMore:
+		// like the combinator, the repetition ends at the position of the
+		// failure of the last attempt
+		comp.compileAtStart(desc.Children[0])
+		comp.emit(instruction{op: opWrap})
+		loop := comp.emit(instruction{op: opChoice})
+		comp.compile(desc.Children[0])
+		comp.emit(instruction{op: opCommit, addr: comp.here() + 1})
+		comp.emit(instruction{op: opAppend})
+		comp.emit(instruction{op: opJump, addr: loop})
+		comp.code[loop].addr = comp.emit(instruction{op: opAdvance})
+
+	case KindZeroOrMore:
+		comp.emit(instruction{op: opNewList})
+		loop := comp.emit(instruction{op: opJumpIfEOF})
+		choice := comp.emit(instruction{op: opChoice})
+		comp.compile(desc.Children[0])
+		comp.emit(instruction{op: opCommit, addr: comp.here() + 1})
+		comp.emit(instruction{op: opAppend})
+		comp.emit(instruction{op: opJump, addr: loop})
+		comp.code[loop].addr = comp.here()
+		comp.code[choice].addr = comp.here()
+
+	case KindOptional:
+		choice := comp.emit(instruction{op: opChoice})
+		comp.compile(desc.Children[0])
+		commit := comp.emit(instruction{op: opCommit})
+		comp.code[choice].addr = comp.emit(instruction{op: opPush})
+		comp.code[commit].addr = comp.here()
+
+	case KindNot:
+		choice := comp.emit(instruction{op: opChoice})
+		comp.compile(desc.Children[0])
+		comp.emit(instruction{op: opNot})
+		comp.code[choice].addr = comp.emit(instruction{op: opPush})
+
+	case KindLookahead:
+		choice := comp.emit(instruction{op: opChoice})
+		comp.compile(desc.Children[0])
+		commit := comp.emit(instruction{op: opBackCommit})
+		comp.code[choice].addr = comp.emit(instruction{op: opFail})
+		comp.code[commit].addr = comp.here()
+
+	case KindTransform:
+		comp.emit(instruction{op: opMark})
+		comp.compileAtStart(desc.Children[0])
+		comp.emit(instruction{op: opTransform, transform: desc.Transform})
+
+	case KindNamed:
+		comp.guarded(desc.Children[0], instruction{op: opNamed, text: desc.Label})
+
+	case KindLabel:
+		comp.guarded(desc.Children[0], instruction{
+			op: opLabel, text: desc.Label,
+			errEnd: fmt.Errorf(`Stream ended, expected %s`, desc.Label),
+			err:    fmt.Errorf(`Expected %s`, desc.Label),
+		})
+
+	default:
+		comp.emit(instruction{op: opFallback, parser: parser})
+	}
+}
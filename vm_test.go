@@ -0,0 +1,159 @@
+package combinators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertCompiledAgrees checks that the compiled parser has the same results
+// and errors of the interpreted one on each input
+func assertCompiledAgrees(t *testing.T, parser Parser, inputs []string, opts ...ParseOption) {
+	program := Compile(parser)
+
+	for _, input := range inputs {
+		expected, expectedErr := ParseRuneReader(parser, strings.NewReader(input), opts...)
+		actual, actualErr := ParseRuneReader(program, strings.NewReader(input), opts...)
+
+		assert.Equal(t, expected, actual, "input %q", input)
+		if expectedErr == nil {
+			assert.Nil(t, actualErr, "input %q", input)
+		} else {
+			assert.EqualError(t, actualErr, expectedErr.Error(), "input %q", input)
+		}
+	}
+}
+
+// expr is a recursive grammar of sums and products, references go through Lazy
+var expr Parser
+
+var exprRef = Lazy(func() Parser {
+	return expr
+})
+
+func init() {
+	number := Named("Number", StringifyResult(OneOrMore(Digit)))
+	value := Named("Value", AnyOf(
+		number,
+		Transform(SeqOf(SeqIgnore(Expect('(')), exprRef, SeqIgnore(Label(Expect(')'), "closing parenthesis"))), func(i interface{}) interface{} {
+			return i.([]interface{})[0]
+		}),
+	))
+	product := Named("Product", SeqOf(value, ZeroOrMore(SeqOf(ExpectAny([]rune("*/")), value))))
+	expr = Named("Sum", SeqOf(product, ZeroOrMore(SeqOf(ExpectAny([]rune("+-")), product))))
+}
+
+func TestCompile(t *testing.T) {
+	assertCompiledAgrees(t, SeqOf(expr, EOF), []string{
+		"1", "1+2*3", "(1+2)*(3-4)/5", "((1))", "", "1+", "(1+2", "1+2)", "1*(2+x)", "12a",
+	})
+
+	// the failed attempt of OneOrMore moves the position, as in the interpreter
+	assertCompiledAgrees(t, SeqOf(OneOrMore(SeqOf(Expect('a'), Expect('b'))), ZeroOrMore(Any)), []string{
+		"ab", "abab", "ababa", "ababac", "b", "",
+	})
+
+	assertCompiledAgrees(t, SeqOf(
+		Optional(ExpectString([]rune("let "))),
+		Not(ExpectString([]rune("if"))),
+		StringifyResult(RepeatUntil(Letter, Lookahead(ExpectAny([]rune(" =;"))))),
+		ZeroOrMore(InlineSpace),
+		ExpectString([]rune("=")),
+		Label(StringifyResult(OneOrMore(Alphanumeric)), "value"),
+		AnyOf(Expect(';'), EOF),
+	), []string{
+		"let x = 1;", "abc=d", "if = 1", "let = 1", "x = ;", "x =", "x = 1 2", "let x", "le", "",
+	})
+
+	// panics of Transforms are located at the beginning of the transformed text
+	assertCompiledAgrees(t, SeqOf(
+		Expect('a'),
+		Named("BC", Transform(SeqOf(Expect('b'), Expect('c')), func(v interface{}) interface{} {
+			return v.(string)
+		})),
+		Expect('d'),
+	), []string{"abcd", "abd", "ad"})
+
+	assertCompiledAgrees(t, AnyOf(), []string{"", "a"})
+	assertCompiledAgrees(t, SeqOf(ExpectAny(nil), ExpectString(nil)), []string{"", "a"})
+}
+
+func TestCompileFallback(t *testing.T) {
+	// FuncParsers, indentation and user state are interpreted between compiled code
+	assertCompiledAgrees(t, tree, []string{" a\n  b\n c\n\t d\n e\n", "a\n b\n   c\n  d\n", "a\n  b\n c\n"})
+
+	assertCompiledAgrees(t, SeqOf(
+		AnyOf(
+			SeqOf(SetState("first"), Expect('a'), Expect('b')),
+			SeqOf(Expect('a'), Expect('c')),
+		),
+		Optional(SeqOf(SetState("optional"), Expect('x'))),
+		GetState(),
+	), []string{"ab", "acy", "acx", "ad"}, WithUserState("initial"))
+
+	skipper := &Skipper{LineComments: []string{"#"}}
+	number := skipper.Lexeme(StringifyResult(OneOrMore(Digit)))
+	assertCompiledAgrees(t, SeqOf(SeqIgnore(skipper.Trivia()), number, SeqIgnore(skipper.Symbol("+")), number, EOF), []string{
+		"1+2", " 1 # one\n+ 2 ", "1 + ", "1 2",
+	})
+
+	{
+		// token states are interpreted too
+		tokens, err := testTokenizer.TokenizeString("let x")
+		assert.Nil(t, err)
+
+		parser := SeqOf(ExpectTokenText("let"), ExpectToken("ident"))
+		expected, err := ParseTokens(parser, tokens)
+		assert.Nil(t, err)
+
+		r, err := ParseTokens(Compile(parser), tokens)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, r)
+	}
+}
+
+func TestProgram(t *testing.T) {
+	program := Compile(Named("AB", SeqOf(Expect('a'), Optional(Expect('b')))))
+
+	assert.Equal(t, KindNamed, Describe(program).Kind)
+	assert.Equal(t, strings.Join([]string{
+		"   0  call 2",
+		"   1  halt",
+		"   2  choice 10",
+		"   3  char 'a'",
+		"   4  choice 7",
+		"   5  char 'b'",
+		"   6  commit 8",
+		"   7  push <nil>",
+		"   8  collect 2",
+		"   9  commit 11",
+		"  10  named AB",
+		"  11  return",
+		"",
+	}, "\n"), program.String())
+
+	{
+		tracer := NewTracer()
+		r, err := ParseRuneReader(program, strings.NewReader("ab"), WithTracer(tracer))
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"a", "b"}, r)
+		assert.Equal(t, "AB", tracer.Roots()[0].Name)
+	}
+}
+
+var benchmarkExpr = strings.Repeat("(12+3)*4-(5/(6+7))+", 50) + "8"
+
+func BenchmarkInterpreter(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ParseRuneReader(expr, strings.NewReader(benchmarkExpr))
+	}
+}
+
+func BenchmarkProgram(b *testing.B) {
+	program := Compile(expr)
+
+	for i := 0; i < b.N; i++ {
+		ParseRuneReader(program, strings.NewReader(benchmarkExpr))
+	}
+}